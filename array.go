@@ -0,0 +1,167 @@
+package lad
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Signed is the set of signed integer types, including named ones.
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is the set of unsigned integer types, including named ones.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is the set of floating-point types, including named ones.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Array constructs a field with the given key and ArrayMarshaler. It provides
+// a flexible, but still type-safe and efficient, way to add array-like types
+// to the logging context. The struct's MarshalLogArray method is called lazily.
+func Array(key string, val zapcore.ArrayMarshaler) Field {
+	return Field{Key: key, Type: zapcore.ArrayMarshalerType, Interface: val}
+}
+
+// Slice constructs a field that carries a slice of any type, encoding each
+// element with appendElem when the entry is written. It covers element types
+// the typed constructors below don't, without reflection:
+//
+//	lad.Slice("ids", ids, func(enc zapcore.ArrayEncoder, id UserID) error {
+//		enc.AppendString(id.String())
+//		return nil
+//	})
+func Slice[T any](key string, vals []T, appendElem func(zapcore.ArrayEncoder, T) error) Field {
+	return Array(key, sliceMarshaler[T]{vals: vals, appendElem: appendElem})
+}
+
+type sliceMarshaler[T any] struct {
+	vals       []T
+	appendElem func(zapcore.ArrayEncoder, T) error
+}
+
+func (s sliceMarshaler[T]) MarshalLogArray(arr zapcore.ArrayEncoder) error {
+	for _, v := range s.vals {
+		if err := s.appendElem(arr, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bools constructs a field that carries a slice of bools.
+func Bools[T ~bool](key string, vals []T) Field {
+	return Slice(key, vals, func(arr zapcore.ArrayEncoder, v T) error {
+		arr.AppendBool(bool(v))
+		return nil
+	})
+}
+
+// Strings constructs a field that carries a slice of strings.
+func Strings[T ~string](key string, vals []T) Field {
+	return Slice(key, vals, func(arr zapcore.ArrayEncoder, v T) error {
+		arr.AppendString(string(v))
+		return nil
+	})
+}
+
+// ByteStrings constructs a field that carries a slice of []byte, each of which
+// must be UTF-8 encoded text.
+func ByteStrings(key string, vals [][]byte) Field {
+	return Slice(key, vals, func(arr zapcore.ArrayEncoder, v []byte) error {
+		arr.AppendByteString(v)
+		return nil
+	})
+}
+
+// Ints constructs a field that carries a slice of signed integers of any
+// size, e.g. []int, []int32 or a slice of a named integer type.
+func Ints[T Signed](key string, vals []T) Field {
+	return Slice(key, vals, func(arr zapcore.ArrayEncoder, v T) error {
+		arr.AppendInt64(int64(v))
+		return nil
+	})
+}
+
+// Uints constructs a field that carries a slice of unsigned integers of any
+// size.
+func Uints[T Unsigned](key string, vals []T) Field {
+	return Slice(key, vals, func(arr zapcore.ArrayEncoder, v T) error {
+		arr.AppendUint64(uint64(v))
+		return nil
+	})
+}
+
+// Floats constructs a field that carries a slice of floats.
+func Floats[T Float](key string, vals []T) Field {
+	return Slice(key, vals, func(arr zapcore.ArrayEncoder, v T) error {
+		arr.AppendFloat64(float64(v))
+		return nil
+	})
+}
+
+// Durations constructs a field that carries a slice of time.Durations. The
+// encoder controls how each duration is serialized.
+func Durations(key string, vals []time.Duration) Field {
+	return Slice(key, vals, func(arr zapcore.ArrayEncoder, v time.Duration) error {
+		arr.AppendDuration(v)
+		return nil
+	})
+}
+
+// Times constructs a field that carries a slice of time.Times. The encoder
+// controls how each time is serialized.
+func Times(key string, vals []time.Time) Field {
+	return Slice(key, vals, func(arr zapcore.ArrayEncoder, v time.Time) error {
+		arr.AppendTime(v)
+		return nil
+	})
+}
+
+// Objects constructs a field with the given key, holding a list of the
+// provided objects that can be marshaled by zap.
+//
+// Note that these objects must implement zapcore.ObjectMarshaler directly.
+// That is, if you're trying to marshal a []Request, the MarshalLogObject
+// method must be declared on the Request type, not its pointer (*Request).
+// If it's on the pointer, use ObjectValues.
+func Objects[T zapcore.ObjectMarshaler](key string, values []T) Field {
+	return zap.Objects(key, values)
+}
+
+// ObjectMarshalerPtr is a constraint that specifies that the given type
+// implements zapcore.ObjectMarshaler on a pointer receiver.
+type ObjectMarshalerPtr[T any] interface {
+	*T
+	zapcore.ObjectMarshaler
+}
+
+// ObjectValues constructs a field with the given key, holding a list of the
+// provided objects, where pointers to these objects can be marshaled by zap.
+//
+// Given an object that implements MarshalLogObject on the pointer receiver,
+// you can log a slice of those objects with ObjectValues like so:
+//
+//	type Request struct{ ... }
+//	func (r *Request) MarshalLogObject(enc zapcore.ObjectEncoder) error
+//
+//	var requests []Request = ...
+//	logger.Info("sending requests", lad.ObjectValues("requests", requests))
+//
+// If instead, you have a slice of pointers of such an object, use Objects.
+func ObjectValues[T any, P ObjectMarshalerPtr[T]](key string, values []T) Field {
+	return zap.ObjectValues[T, P](key, values)
+}
+
+// Stringers constructs a field with the given key, holding a list of the
+// output provided by the value's String method.
+func Stringers[T fmt.Stringer](key string, values []T) Field {
+	return zap.Stringers(key, values)
+}
@@ -0,0 +1,65 @@
+package lad
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type status string
+
+type point struct{ x, y int }
+
+func (p *point) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("x", p.x)
+	enc.AddInt("y", p.y)
+	return nil
+}
+
+func TestArrayFields(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Info("arrays",
+		Strings("statuses", []status{"ok", "failed"}),
+		Ints("ints", []int32{1, -2}),
+		Uints("uints", []uint8{7}),
+		Floats("floats", []float64{1.5}),
+		Bools("bools", []bool{true, false}),
+		Durations("durations", []time.Duration{1500 * time.Millisecond}),
+		Times("times", []time.Time{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}),
+		Errors("errors", []error{errors.New("a"), nil}),
+		ObjectValues("points", []point{{1, 2}}),
+		Stringers("ips", []net.IP{net.IPv4(10, 0, 0, 1)}),
+		Slice("lens", []string{"ab", "c"}, func(arr zapcore.ArrayEncoder, s string) error {
+			arr.AppendInt(len(s))
+			return nil
+		}),
+	)
+
+	got := out.String()
+	for _, want := range []string{
+		`"statuses":["ok","failed"]`,
+		`"ints":[1,-2]`,
+		`"uints":[7]`,
+		`"floats":[1.5]`,
+		`"bools":[true,false]`,
+		`"durations":[1.5]`,
+		`"times":["2024-01-02`,
+		`"errors":[{"error":"a"}]`,
+		`"points":[{"x":1,"y":2}]`,
+		`"ips":["10.0.0.1"]`,
+		`"lens":[2,1]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %s does not contain %s", got, want)
+		}
+	}
+}
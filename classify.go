@@ -0,0 +1,136 @@
+package lad
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"strconv"
+	"syscall"
+)
+
+// ErrorKind is a broad, stable category of failure, such as ErrorKindTimeout.
+type ErrorKind string
+
+const (
+	// ErrorKindCanceled is for operations the caller gave up on.
+	ErrorKindCanceled ErrorKind = "canceled"
+	// ErrorKindTimeout is for operations that ran out of time.
+	ErrorKindTimeout ErrorKind = "timeout"
+	// ErrorKindNotFound is for missing resources.
+	ErrorKindNotFound ErrorKind = "not_found"
+	// ErrorKindAlreadyExists is for resources that unexpectedly exist.
+	ErrorKindAlreadyExists ErrorKind = "already_exists"
+	// ErrorKindPermissionDenied is for operations the caller may not perform.
+	ErrorKindPermissionDenied ErrorKind = "permission_denied"
+	// ErrorKindUnavailable is for dependencies that can't be reached right now.
+	ErrorKindUnavailable ErrorKind = "unavailable"
+	// ErrorKindInvalid is for invalid input.
+	ErrorKindInvalid ErrorKind = "invalid"
+	// ErrorKindInternal is for bugs and broken invariants.
+	ErrorKindInternal ErrorKind = "internal"
+)
+
+// ErrorClass classifies an error. Error fields log it under key+".code",
+// key+".kind" and key+".retryable", e.g. "error.code"; an empty Code or Kind
+// is omitted.
+type ErrorClass struct {
+	Code      string
+	Kind      ErrorKind
+	Retryable bool
+}
+
+// ClassifiedError is implemented by errors that know their classification:
+//
+//	func (e *QuotaError) ErrorClass() lad.ErrorClass {
+//		return lad.ErrorClass{Code: "quota_exceeded", Kind: lad.ErrorKindUnavailable, Retryable: true}
+//	}
+type ClassifiedError interface {
+	error
+	ErrorClass() ErrorClass
+}
+
+// ClassifyError returns the classification Error fields log for err: that of
+// the outermost error in its chain implementing ClassifiedError, or else one
+// derived from well-known errors found in the chain: context.Canceled,
+// context.DeadlineExceeded, fs.ErrNotExist (os.ErrNotExist), fs.ErrExist,
+// fs.ErrPermission, net.Error timeouts and syscall.Errno values. It reports
+// false if err has no known classification.
+func ClassifyError(err error) (ErrorClass, bool) {
+	if err == nil {
+		return ErrorClass{}, false
+	}
+	var ce ClassifiedError
+	if errors.As(err, &ce) {
+		return ce.ErrorClass(), true
+	}
+
+	var class ErrorClass
+	var errno syscall.Errno
+	if errors.As(err, &errno) && errno != 0 {
+		class.Code = errnoCode(errno)
+		class.Kind = errnoKinds[errno]
+		class.Retryable = errno.Temporary() || errno.Timeout() || class.Kind == ErrorKindUnavailable
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		class.Kind, class.Retryable = ErrorKindCanceled, false
+	case errors.Is(err, context.DeadlineExceeded):
+		class.Kind, class.Retryable = ErrorKindTimeout, true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		class.Kind, class.Retryable = ErrorKindTimeout, true
+	case errors.Is(err, fs.ErrNotExist):
+		class.Kind, class.Retryable = ErrorKindNotFound, false
+	case errors.Is(err, fs.ErrExist):
+		class.Kind, class.Retryable = ErrorKindAlreadyExists, false
+	case errors.Is(err, fs.ErrPermission):
+		class.Kind, class.Retryable = ErrorKindPermissionDenied, false
+	}
+	return class, class != ErrorClass{}
+}
+
+// errnoNames are the codes of the errnos worth telling apart in logs; others
+// are logged by number.
+var errnoNames = map[syscall.Errno]string{
+	syscall.EPERM:        "EPERM",
+	syscall.ENOENT:       "ENOENT",
+	syscall.EINTR:        "EINTR",
+	syscall.EIO:          "EIO",
+	syscall.EBADF:        "EBADF",
+	syscall.EAGAIN:       "EAGAIN",
+	syscall.ENOMEM:       "ENOMEM",
+	syscall.EACCES:       "EACCES",
+	syscall.EEXIST:       "EEXIST",
+	syscall.ENOTDIR:      "ENOTDIR",
+	syscall.EISDIR:       "EISDIR",
+	syscall.EINVAL:       "EINVAL",
+	syscall.EMFILE:       "EMFILE",
+	syscall.ENOSPC:       "ENOSPC",
+	syscall.EPIPE:        "EPIPE",
+	syscall.EADDRINUSE:   "EADDRINUSE",
+	syscall.ENETUNREACH:  "ENETUNREACH",
+	syscall.ECONNABORTED: "ECONNABORTED",
+	syscall.ECONNRESET:   "ECONNRESET",
+	syscall.ETIMEDOUT:    "ETIMEDOUT",
+	syscall.ECONNREFUSED: "ECONNREFUSED",
+	syscall.EHOSTUNREACH: "EHOSTUNREACH",
+}
+
+var errnoKinds = map[syscall.Errno]ErrorKind{
+	syscall.EINVAL:       ErrorKindInvalid,
+	syscall.EPIPE:        ErrorKindUnavailable,
+	syscall.ENETUNREACH:  ErrorKindUnavailable,
+	syscall.ECONNABORTED: ErrorKindUnavailable,
+	syscall.ECONNRESET:   ErrorKindUnavailable,
+	syscall.ETIMEDOUT:    ErrorKindTimeout,
+	syscall.ECONNREFUSED: ErrorKindUnavailable,
+	syscall.EHOSTUNREACH: ErrorKindUnavailable,
+}
+
+func errnoCode(errno syscall.Errno) string {
+	if name, ok := errnoNames[errno]; ok {
+		return name
+	}
+	return "errno_" + strconv.FormatUint(uint64(errno), 10)
+}
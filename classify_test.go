@@ -0,0 +1,64 @@
+package lad
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+type quotaError struct{}
+
+func (quotaError) Error() string { return "quota exceeded" }
+
+func (quotaError) ErrorClass() ErrorClass {
+	return ErrorClass{Code: "quota_exceeded", Kind: ErrorKindUnavailable, Retryable: true}
+}
+
+func TestClassifyError(t *testing.T) {
+	statErr := &os.PathError{Op: "stat", Path: "/does/not/exist", Err: syscall.ENOENT}
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"custom", fmt.Errorf("call: %w", quotaError{}), ErrorClass{"quota_exceeded", ErrorKindUnavailable, true}},
+		{"canceled", fmt.Errorf("query: %w", context.Canceled), ErrorClass{Kind: ErrorKindCanceled}},
+		{"deadline", context.DeadlineExceeded, ErrorClass{Kind: ErrorKindTimeout, Retryable: true}},
+		{"not exist", WrapErr(statErr), ErrorClass{Code: "ENOENT", Kind: ErrorKindNotFound}},
+		{"errno", &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}, ErrorClass{"ECONNREFUSED", ErrorKindUnavailable, true}},
+		{"os timeout", os.ErrDeadlineExceeded, ErrorClass{Kind: ErrorKindTimeout, Retryable: true}},
+	}
+	for _, tt := range tests {
+		got, ok := ClassifyError(tt.err)
+		if !ok || got != tt.want {
+			t.Errorf("%s: ClassifyError=%+v, %v; want %+v", tt.name, got, ok, tt.want)
+		}
+	}
+	if _, ok := ClassifyError(errors.New("plain")); ok {
+		t.Error("plain error was classified")
+	}
+}
+
+func TestErrorClassFields(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Error("call failed", Error(quotaError{}), NamedError("cleanup", errors.New("plain")))
+
+	got := out.String()
+	for _, want := range []string{`"error.code":"quota_exceeded"`, `"error.kind":"unavailable"`, `"error.retryable":true`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %s does not contain %s", got, want)
+		}
+	}
+	if strings.Contains(got, "cleanup.") {
+		t.Errorf("output %s classifies a plain error", got)
+	}
+}
@@ -0,0 +1,242 @@
+package lad
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultIndexTemplate names one index per day, e.g. "logs-2024.01.02".
+const DefaultIndexTemplate = "logs-{2006.01.02}"
+
+// ElasticsearchConfig controls output to the Elasticsearch/OpenSearch bulk API.
+type ElasticsearchConfig struct {
+	Level   zapcore.Level
+	Enabler zapcore.LevelEnabler // Overrides Level when set (see LevelRange).
+
+	URL string // Cluster base URL, e.g. "http://localhost:9200". Required.
+
+	// Index is the target index. A Go time layout inside braces is replaced
+	// with the entry time, e.g. "app-{2006.01.02}" for daily indices. Times
+	// are rendered in the zone of WithTimeZone, or UTC when unset.
+	// Defaults to DefaultIndexTemplate.
+	Index string
+
+	Header      http.Header  // Extra request headers (e.g. Authorization).
+	Client      *http.Client // Defaults to a client with a 10s timeout.
+	DisableGzip bool         // Send uncompressed bodies.
+
+	TimeFormat string        // Defaults to DefaultTimeFormat when empty.
+	Encoder    EncoderConfig // Key names and value formats; zero value keeps defaults.
+
+	BatchSize     int           // Max entries per request. Defaults to 500.
+	BatchBytes    int           // Max encoded bytes per request. Defaults to 5 MiB.
+	FlushInterval time.Duration // Max time an entry waits for its batch. Defaults to 1s.
+	BufferBytes   int           // In-memory buffer. Defaults to 4 MiB.
+	MaxRetries    int           // Attempts after the first failure. Defaults to 5.
+	MinBackoff    time.Duration // First retry delay. Defaults to 100ms.
+	MaxBackoff    time.Duration // Retry delay cap. Defaults to 30s.
+	FlushTimeout  time.Duration // How long Sync waits for the buffer to drain. Defaults to 5s.
+
+	// DeadLetterFile receives, as JSON lines, entries the cluster rejected
+	// (e.g. mapping errors) or that still failed after MaxRetries.
+	DeadLetterFile string
+
+	SpoolDir   string // Optional; durable on-disk buffer (see NetworkConfig.SpoolDir).
+	SpoolBytes int    // Disk cap of SpoolDir. Defaults to 256 MiB.
+
+	Stats *SinkStats // Optional; receives delivery and drop counters.
+}
+
+// WithElasticsearch adds a core that writes entries through the _bulk API.
+//
+// Each item of a bulk response is checked: items rejected with 429 or a 5xx
+// status are retried with backoff (back-pressure), other rejected items go to
+// the dead-letter file. Sync flushes the pending batch.
+func WithElasticsearch(ec ElasticsearchConfig) Option {
+	return func(c *config) error {
+		c.coreBuilders = append(c.coreBuilders, func(cfg *config) (zapcore.Core, error) {
+			base := strings.TrimRight(strings.TrimSpace(ec.URL), "/")
+			if base == "" {
+				return nil, errors.New("lad: ElasticsearchConfig.URL is required")
+			}
+			index, err := parseIndexTemplate(orDefault(ec.Index, DefaultIndexTemplate))
+			if err != nil {
+				return nil, err
+			}
+			loc := cfg.location
+			if loc == nil {
+				loc = time.UTC
+			}
+
+			encCfg, err := cfg.encoderConfig(ec.Encoder, ec.TimeFormat, false)
+			if err != nil {
+				return nil, err
+			}
+			encCfg.LineEnding = "\n"
+
+			es := &esSender{
+				url:        base + "/_bulk",
+				header:     ec.Header,
+				client:     ec.Client,
+				gzip:       !ec.DisableGzip,
+				deadLetter: ec.DeadLetterFile,
+			}
+			var closeSender func() error
+			if es.client == nil {
+				es.client = &http.Client{Timeout: 10 * time.Second}
+				closeSender = closeIdleConns(es.client)
+			}
+
+			queue, err := newRecordQueue(ec.SpoolDir, ec.SpoolBytes, ec.BufferBytes)
+			if err != nil {
+				return nil, err
+			}
+			s := cfg.ship(&shipper{
+				deliver:       es.deliver,
+				closeSender:   closeSender,
+				queue:         queue,
+				maxBatch:      orDefaultInt(ec.BatchSize, 500),
+				maxBatchBytes: orDefaultInt(ec.BatchBytes, 5<<20),
+				flushInterval: orDefaultDuration(ec.FlushInterval, time.Second),
+				flushTimeout:  ec.FlushTimeout,
+				minBackoff:    ec.MinBackoff,
+				maxBackoff:    ec.MaxBackoff,
+				maxRetries:    orDefaultInt(ec.MaxRetries, 5),
+				giveUp:        es.giveUp,
+				stats:         ec.Stats,
+			})
+
+			core := &recordCore{
+				LevelEnabler: levelEnabler(ec.Enabler, ec.Level),
+				enc:          zapcore.NewJSONEncoder(encCfg),
+				record: func(ent zapcore.Entry, _ []Field, line []byte) ([]byte, error) {
+					action, err := json.Marshal(map[string]map[string]string{
+						"create": {"_index": index(ent.Time.In(loc))},
+					})
+					if err != nil {
+						return nil, err
+					}
+					rec := make([]byte, 0, len(action)+1+len(line))
+					rec = append(rec, action...)
+					rec = append(rec, '\n')
+					return append(rec, line...), nil
+				},
+				out: s,
+			}
+			return core, nil
+		})
+		return nil
+	}
+}
+
+// parseIndexTemplate splits "prefix-{layout}-suffix" into a naming function.
+func parseIndexTemplate(tmpl string) (func(time.Time) string, error) {
+	start := strings.IndexByte(tmpl, '{')
+	if start < 0 {
+		return func(time.Time) string { return tmpl }, nil
+	}
+	end := strings.IndexByte(tmpl[start:], '}')
+	if end < 0 {
+		return nil, fmt.Errorf("lad: unterminated time layout in index %q", tmpl)
+	}
+	prefix, layout, suffix := tmpl[:start], tmpl[start+1:start+end], tmpl[start+end+1:]
+	if strings.ContainsAny(suffix, "{}") {
+		return nil, fmt.Errorf("lad: index %q may contain only one time layout", tmpl)
+	}
+	return func(t time.Time) string {
+		return prefix + t.Format(layout) + suffix
+	}, nil
+}
+
+// esSender posts bulk requests; it is only used from the shipper goroutine.
+type esSender struct {
+	url        string
+	header     http.Header
+	client     *http.Client
+	gzip       bool
+	deadLetter string
+}
+
+type bulkResponse struct {
+	Errors bool                         `json:"errors"`
+	Items  []map[string]bulkItemOutcome `json:"items"`
+}
+
+type bulkItemOutcome struct {
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error"`
+}
+
+func (es *esSender) deliver(batch [][]byte) error {
+	var body bytes.Buffer
+	for _, rec := range batch {
+		body.Write(rec)
+	}
+	respBody, err := postBody(es.client, es.url, es.header, "application/x-ndjson", body.Bytes(), es.gzip)
+	if err != nil {
+		return err
+	}
+
+	var resp bulkResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("lad: decode bulk response: %w", err)
+	}
+	if !resp.Errors {
+		return nil
+	}
+	if len(resp.Items) != len(batch) {
+		return fmt.Errorf("lad: bulk response has %d items for %d entries", len(resp.Items), len(batch))
+	}
+
+	var (
+		retry    [][]byte
+		rejected [][]byte
+		firstErr string
+	)
+	for i, item := range resp.Items {
+		for _, outcome := range item {
+			switch {
+			case outcome.Status/100 == 2:
+			case retryableStatus(outcome.Status):
+				retry = append(retry, batch[i])
+			default:
+				rejected = append(rejected, batch[i])
+			}
+			if outcome.Status/100 != 2 && firstErr == "" {
+				firstErr = fmt.Sprintf("status %d: %s", outcome.Status, outcome.Error)
+			}
+		}
+	}
+	if len(retry) == 0 && len(rejected) == 0 {
+		return nil
+	}
+	return &partialError{
+		err:      fmt.Errorf("lad: bulk: %d of %d items failed, first: %s", len(retry)+len(rejected), len(batch), firstErr),
+		retry:    retry,
+		rejected: rejected,
+	}
+}
+
+// giveUp appends the documents of failed bulk items to the dead-letter file.
+func (es *esSender) giveUp(batch [][]byte, _ error) {
+	if es.deadLetter == "" {
+		return
+	}
+	var buf bytes.Buffer
+	for _, rec := range batch {
+		// Drop the action line, keep the document.
+		if i := bytes.IndexByte(rec, '\n'); i >= 0 {
+			rec = rec[i+1:]
+		}
+		buf.Write(bytes.TrimRight(rec, "\n"))
+		buf.WriteByte('\n')
+	}
+	_ = appendDeadLetter(es.deadLetter, buf.Bytes())
+}
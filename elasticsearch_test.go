@@ -0,0 +1,99 @@
+package lad
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithElasticsearchPartialFailures(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests [][]string // messages per request
+		indices  []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			t.Errorf("path=%q, want /_bulk", r.URL.Path)
+		}
+		var msgs []string
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var action map[string]map[string]string
+			_ = json.Unmarshal(sc.Bytes(), &action)
+			sc.Scan()
+			var doc map[string]any
+			_ = json.Unmarshal(sc.Bytes(), &doc)
+			msgs = append(msgs, doc["msg"].(string))
+			mu.Lock()
+			indices = append(indices, action["create"]["_index"])
+			mu.Unlock()
+		}
+
+		// "busy" is throttled once, "bad" is always rejected.
+		var items []string
+		hasErrors := false
+		mu.Lock()
+		first := len(requests) == 0
+		requests = append(requests, msgs)
+		mu.Unlock()
+		for _, msg := range msgs {
+			status := 201
+			switch {
+			case msg == "busy" && first:
+				status = 429
+			case msg == "bad":
+				status = 400
+			}
+			hasErrors = hasErrors || status != 201
+			items = append(items, fmt.Sprintf(`{"create":{"status":%d}}`, status))
+		}
+		fmt.Fprintf(w, `{"errors":%v,"items":[%s]}`, hasErrors, strings.Join(items, ","))
+	}))
+	defer srv.Close()
+
+	deadLetter := filepath.Join(t.TempDir(), "dead.jsonl")
+	stats := &SinkStats{}
+	l, err := New(WithElasticsearch(ElasticsearchConfig{
+		URL:            srv.URL,
+		Index:          "app-{2006.01}",
+		DisableGzip:    true,
+		FlushInterval:  time.Hour, // only Sync flushes
+		MinBackoff:     time.Millisecond,
+		DeadLetterFile: deadLetter,
+		Stats:          stats,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Info("ok")
+	l.Info("busy")
+	l.Info("bad")
+	if err := l.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 2 || strings.Join(requests[1], ",") != "busy" {
+		t.Fatalf("requests=%v, want a retry of just busy", requests)
+	}
+	if want := "app-" + time.Now().UTC().Format("2006.01"); indices[0] != want {
+		t.Fatalf("index=%q, want %q", indices[0], want)
+	}
+	data, _ := os.ReadFile(deadLetter)
+	if !strings.Contains(string(data), `"msg":"bad"`) || strings.Contains(string(data), "create") {
+		t.Fatalf("dead letter=%q", data)
+	}
+	if stats.SentEntries() != 2 || stats.DroppedEntries() != 1 {
+		t.Fatalf("sent=%d dropped=%d", stats.SentEntries(), stats.DroppedEntries())
+	}
+}
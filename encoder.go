@@ -0,0 +1,240 @@
+package lad
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// OmitKey can be used for any EncoderConfig key to drop that portion of the
+// entry entirely.
+const OmitKey = "-"
+
+// TimeEncoding controls how entry timestamps and Time fields are rendered.
+type TimeEncoding string
+
+const (
+	// LayoutTimeEncoding formats times with the configured TimeFormat (default).
+	LayoutTimeEncoding TimeEncoding = "layout"
+	// RFC3339TimeEncoding formats times as RFC3339 strings with second precision.
+	RFC3339TimeEncoding TimeEncoding = "rfc3339"
+	// RFC3339NanoTimeEncoding formats times as RFC3339 strings with nanosecond precision.
+	RFC3339NanoTimeEncoding TimeEncoding = "rfc3339nano"
+	// ISO8601TimeEncoding formats times as ISO8601 strings with millisecond precision.
+	ISO8601TimeEncoding TimeEncoding = "iso8601"
+	// ISO8601UTCTimeEncoding is like ISO8601TimeEncoding but always renders UTC ("Z").
+	ISO8601UTCTimeEncoding TimeEncoding = "iso8601utc"
+	// EpochTimeEncoding writes floating-point seconds since the Unix epoch.
+	EpochTimeEncoding TimeEncoding = "epoch"
+	// EpochMillisTimeEncoding writes floating-point milliseconds since the Unix epoch.
+	EpochMillisTimeEncoding TimeEncoding = "epochmillis"
+	// EpochNanosTimeEncoding writes integer nanoseconds since the Unix epoch.
+	EpochNanosTimeEncoding TimeEncoding = "epochnanos"
+)
+
+// DurationEncoding controls how Duration fields are rendered.
+type DurationEncoding string
+
+const (
+	// SecondsDurationEncoding writes floating-point seconds (default).
+	SecondsDurationEncoding DurationEncoding = "seconds"
+	// MillisDurationEncoding writes floating-point milliseconds.
+	MillisDurationEncoding DurationEncoding = "millis"
+	// NanosDurationEncoding writes integer nanoseconds.
+	NanosDurationEncoding DurationEncoding = "nanos"
+	// StringDurationEncoding writes time.Duration.String() (e.g. "1.5s").
+	StringDurationEncoding DurationEncoding = "string"
+)
+
+// LevelEncoding controls the casing of the level name.
+type LevelEncoding string
+
+const (
+	// CapitalLevelEncoding writes "INFO", "WARN", ... (default).
+	CapitalLevelEncoding LevelEncoding = "capital"
+	// LowercaseLevelEncoding writes "info", "warn", ...
+	LowercaseLevelEncoding LevelEncoding = "lowercase"
+)
+
+// EncoderConfig customizes key names and value formats of an output.
+// The zero value keeps lad's defaults. Set a key to OmitKey to drop it.
+type EncoderConfig struct {
+	TimeKey       string // Defaults to "ts".
+	LevelKey      string // Defaults to "level".
+	NameKey       string // Defaults to "logger".
+	CallerKey     string // Defaults to "caller".
+	FunctionKey   string // Omitted when empty.
+	MessageKey    string // Defaults to "msg".
+	StacktraceKey string // Defaults to "stacktrace".
+
+	TimeEncoding     TimeEncoding     // Defaults to LayoutTimeEncoding.
+	DurationEncoding DurationEncoding // Defaults to SecondsDurationEncoding.
+	LevelEncoding    LevelEncoding    // Defaults to CapitalLevelEncoding.
+}
+
+// encoderConfig turns ec into a zap encoder config, applying lad's defaults
+// and the logger-wide settings held in cfg.
+func (cfg *config) encoderConfig(ec EncoderConfig, timeFormat string, colored bool) (zapcore.EncoderConfig, error) {
+	encCfg := zap.NewProductionEncoderConfig()
+	encCfg.TimeKey = encoderKey(ec.TimeKey, encCfg.TimeKey)
+	encCfg.LevelKey = encoderKey(ec.LevelKey, encCfg.LevelKey)
+	encCfg.NameKey = encoderKey(ec.NameKey, encCfg.NameKey)
+	encCfg.CallerKey = encoderKey(ec.CallerKey, encCfg.CallerKey)
+	encCfg.FunctionKey = encoderKey(ec.FunctionKey, zapcore.OmitKey)
+	encCfg.MessageKey = encoderKey(ec.MessageKey, encCfg.MessageKey)
+	encCfg.StacktraceKey = encoderKey(ec.StacktraceKey, encCfg.StacktraceKey)
+	encCfg.EncodeCaller = cfg.callerEncode
+
+	switch ec.TimeEncoding {
+	case "", LayoutTimeEncoding:
+		encCfg.EncodeTime = timeEncoder(orDefault(timeFormat, DefaultTimeFormat))
+	case RFC3339TimeEncoding:
+		encCfg.EncodeTime = zapcore.RFC3339TimeEncoder
+	case RFC3339NanoTimeEncoding:
+		encCfg.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	case ISO8601TimeEncoding:
+		encCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	case ISO8601UTCTimeEncoding:
+		encCfg.EncodeTime = func(t time.Time, pae zapcore.PrimitiveArrayEncoder) {
+			zapcore.ISO8601TimeEncoder(t.UTC(), pae)
+		}
+	case EpochTimeEncoding:
+		encCfg.EncodeTime = zapcore.EpochTimeEncoder
+	case EpochMillisTimeEncoding:
+		encCfg.EncodeTime = zapcore.EpochMillisTimeEncoder
+	case EpochNanosTimeEncoding:
+		encCfg.EncodeTime = zapcore.EpochNanosTimeEncoder
+	default:
+		return encCfg, fmt.Errorf("lad: unknown TimeEncoding %q", ec.TimeEncoding)
+	}
+
+	if loc := cfg.location; loc != nil {
+		encodeTime := encCfg.EncodeTime
+		encCfg.EncodeTime = func(t time.Time, pae zapcore.PrimitiveArrayEncoder) {
+			encodeTime(t.In(loc), pae)
+		}
+	}
+
+	switch ec.DurationEncoding {
+	case "", SecondsDurationEncoding:
+		encCfg.EncodeDuration = zapcore.SecondsDurationEncoder
+	case MillisDurationEncoding:
+		encCfg.EncodeDuration = zapcore.MillisDurationEncoder
+	case NanosDurationEncoding:
+		encCfg.EncodeDuration = zapcore.NanosDurationEncoder
+	case StringDurationEncoding:
+		encCfg.EncodeDuration = zapcore.StringDurationEncoder
+	default:
+		return encCfg, fmt.Errorf("lad: unknown DurationEncoding %q", ec.DurationEncoding)
+	}
+
+	switch ec.LevelEncoding {
+	case "", CapitalLevelEncoding:
+		encCfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		if colored {
+			encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+	case LowercaseLevelEncoding:
+		encCfg.EncodeLevel = zapcore.LowercaseLevelEncoder
+		if colored {
+			encCfg.EncodeLevel = zapcore.LowercaseColorLevelEncoder
+		}
+	default:
+		return encCfg, fmt.Errorf("lad: unknown LevelEncoding %q", ec.LevelEncoding)
+	}
+
+	return encCfg, nil
+}
+
+func encoderKey(key, def string) string {
+	switch key {
+	case "":
+		return def
+	case OmitKey:
+		return zapcore.OmitKey
+	default:
+		return key
+	}
+}
+
+// consoleFormatter is implemented by Reflect field values that console
+// output renders differently from JSON, such as RawJSON.
+type consoleFormatter interface {
+	consoleField(key string) Field
+}
+
+// plainValuer is implemented by Reflect field values that outputs building a
+// map of fields, such as OTLP, GELF and syslog, should log as a plain number
+// rather than through fmt.
+type plainValuer interface {
+	plainValue() any
+}
+
+// consoleStacker is implemented by field values carrying a stack trace, which
+// console output prints below the entry, like the entry's own stack. It
+// returns the field to encode in its place and the indented stack, if any.
+type consoleStacker interface {
+	consoleStack() (Field, string)
+}
+
+// consoleEncoder applies consoleFormatter and consoleStacker to the fields of
+// zap's console encoder.
+type consoleEncoder struct {
+	zapcore.Encoder
+	stacks bool // whether entry stacks are printed
+}
+
+func (c consoleEncoder) Clone() zapcore.Encoder {
+	return consoleEncoder{c.Encoder.Clone(), c.stacks}
+}
+
+// AddReflected covers fields added with Logger.With.
+func (c consoleEncoder) AddReflected(key string, v any) error {
+	if f, ok := v.(consoleFormatter); ok {
+		f.consoleField(key).AddTo(c.Encoder)
+		return nil
+	}
+	return c.Encoder.AddReflected(key, v)
+}
+
+func (c consoleEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	copied := false
+	for i, f := range fields {
+		var (
+			field Field
+			stack string
+		)
+		switch v := f.Interface.(type) {
+		case consoleFormatter:
+			if f.Type != zapcore.ReflectType {
+				continue
+			}
+			field = v.consoleField(f.Key)
+		case consoleStacker:
+			if !c.stacks {
+				continue
+			}
+			if field, stack = v.consoleStack(); stack == "" {
+				continue
+			}
+		default:
+			continue
+		}
+		if !copied {
+			fields = slices.Clone(fields)
+			copied = true
+		}
+		fields[i] = field
+		if stack != "" {
+			if ent.Stack != "" {
+				ent.Stack += "\n"
+			}
+			ent.Stack += stack
+		}
+	}
+	return c.Encoder.EncodeEntry(ent, fields)
+}
@@ -0,0 +1,124 @@
+package lad
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestEncoderConfigKeysAndFormats(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "enc.log")
+	l, err := New(
+		WithFile(FileConfig{
+			Level:    zapcore.InfoLevel,
+			Filename: logFile,
+			Encoder: EncoderConfig{
+				TimeKey:          "@timestamp",
+				LevelKey:         "severity",
+				MessageKey:       "message",
+				CallerKey:        OmitKey,
+				TimeEncoding:     EpochMillisTimeEncoding,
+				DurationEncoding: StringDurationEncoding,
+				LevelEncoding:    LowercaseLevelEncoding,
+			},
+		}),
+		WithCaller(),
+	)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Warn("probe", Duration("took", 1500*time.Millisecond))
+	_ = Sync(l)
+
+	row := readLastJSONLine(t, logFile)
+	if row["severity"] != "warn" {
+		t.Fatalf("severity=%v, want warn", row["severity"])
+	}
+	if row["message"] != "probe" {
+		t.Fatalf("message=%v, want probe", row["message"])
+	}
+	if _, ok := row["@timestamp"].(float64); !ok {
+		t.Fatalf("@timestamp=%v, want epoch millis number", row["@timestamp"])
+	}
+	if row["took"] != "1.5s" {
+		t.Fatalf("took=%v, want 1.5s", row["took"])
+	}
+	if _, ok := row["caller"]; ok {
+		t.Fatalf("caller present, want omitted: %v", row)
+	}
+}
+
+func TestEncoderConfigUnknownEncoding(t *testing.T) {
+	_, err := New(WithConsole(ConsoleConfig{
+		Encoder: EncoderConfig{TimeEncoding: "bogus"},
+	}))
+	if err == nil || !strings.Contains(err.Error(), "TimeEncoding") {
+		t.Fatalf("err=%v, want unknown TimeEncoding error", err)
+	}
+}
+
+func TestWithTimeZone(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(
+		WithWriter(WriterConfig{
+			Level:   zapcore.InfoLevel,
+			Writer:  &out,
+			Encoder: EncoderConfig{TimeEncoding: RFC3339TimeEncoding},
+		}),
+		WithTimeZone("Asia/Tokyo"),
+	)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	l.Info("probe", Time("at", at))
+
+	var row map[string]any
+	if err := json.Unmarshal(out.Bytes(), &row); err != nil {
+		t.Fatalf("decode %q: %v", out.String(), err)
+	}
+	if row["at"] != "2024-01-02T12:04:05+09:00" {
+		t.Fatalf("at=%v, want Tokyo time", row["at"])
+	}
+	if ts, _ := row["ts"].(string); !strings.HasSuffix(ts, "+09:00") {
+		t.Fatalf("ts=%v, want +09:00 offset", row["ts"])
+	}
+
+	if _, err := New(WithTimeZone("Nowhere/Atlantis")); err == nil {
+		t.Fatal("expected error for unknown zone")
+	}
+}
+
+func TestWithTimeZoneFileRotation(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "tz.log")
+	for _, zone := range []string{"UTC", "Local"} {
+		if _, err := New(WithFile(FileConfig{Filename: logFile}), WithTimeZone(zone)); err != nil {
+			t.Fatalf("%s: %v", zone, err)
+		}
+	}
+	_, err := New(WithFile(FileConfig{Filename: logFile}), WithTimeZone("Asia/Tokyo"))
+	if err == nil || !strings.Contains(err.Error(), "rotated file names") {
+		t.Fatalf("err=%v, want rotation zone error", err)
+	}
+}
+
+func readLastJSONLine(t *testing.T, file string) map[string]any {
+	t.Helper()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var row map[string]any
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &row); err != nil {
+		t.Fatalf("parse json log line: %v", err)
+	}
+	return row
+}
@@ -1,6 +1,14 @@
 package lad
 
-import "go.uber.org/zap/zapcore"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
 
 // Error is shorthand for the common idiom NamedError("error", err).
 func Error(err error) Field {
@@ -12,11 +20,313 @@ func Error(err error) Field {
 // by github.com/pkg/errors) will also have their verbose representation stored
 // under key+"Verbose". If passed a nil error, the field is a no-op.
 //
+// The errors err wraps, found with errors.Unwrap and through errors.Join
+// trees, add the fields attached to them with WrapErr next to key, and their
+// messages, when they differ from the wrapping error's, under key+"Causes" as
+// objects with an "error" key, like zap does for multierr errors.
+// The stack recorded by StackErr or Errorf is added under key+"Stack", as an
+// array of frames in JSON and below the entry in console output; when several
+// layers recorded one, the innermost, closest to the origin, is used. The
+// classification reported by ClassifyError is added under key+".code",
+// key+".kind" and key+".retryable".
+//
 // For the common case in which the key is simply "error", the Error function
 // is shorter and less repetitive.
 func NamedError(key string, err error) Field {
 	if err == nil {
 		return Skip()
 	}
-	return Field{Key: key, Type: zapcore.ErrorType, Interface: err}
+	return Field{Key: key, Type: zapcore.InlineMarshalerType, Interface: errorMarshaler{key: key, err: err}}
+}
+
+// Errors constructs a field that carries a slice of errors, each encoded as
+// an object with an "error" key, along with its fields, causes, stack and
+// classification, like Error. Nil errors are skipped.
+func Errors(key string, errs []error) Field {
+	return Array(key, errorArray(errs))
+}
+
+type errorArray []error
+
+func (a errorArray) MarshalLogArray(arr zapcore.ArrayEncoder) error {
+	for _, err := range a {
+		if err == nil {
+			continue
+		}
+		if err := arr.AppendObject(errorMarshaler{key: "error", err: err}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WrapErr returns an error that carries fields, so context known deep in the
+// call stack reaches the log line of whoever finally logs the error:
+//
+//	if err != nil {
+//		return lad.WrapErr(err, lad.String("path", path), lad.Int("attempt", n))
+//	}
+//	...
+//	logger.Error("sync failed", lad.Error(err)) // includes path and attempt
+//
+// The returned error has the same message as err and unwraps to it, so
+// errors.Is and errors.As work as before. WrapErr returns nil if err is nil.
+// When layers attach fields with the same key, the outermost one wins.
+func WrapErr(err error, fields ...Field) error {
+	if err == nil {
+		return nil
+	}
+	return &fieldError{err: err, fields: fields}
+}
+
+type fieldError struct {
+	err    error
+	fields []Field
+}
+
+func (e *fieldError) Error() string { return e.err.Error() }
+
+func (e *fieldError) Unwrap() error { return e.err }
+
+// errorGroup is implemented by multi-errors such as go.uber.org/multierr's.
+type errorGroup interface {
+	Errors() []error
+}
+
+// unwrapErrors returns the errors err directly wraps.
+func unwrapErrors(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			return []error{inner}
+		}
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	case errorGroup:
+		return e.Errors()
+	}
+	return nil
+}
+
+// StackErr returns an error that records the stack of its caller, for Error
+// fields to log where the error came from rather than where it was logged.
+// The returned error has the same message as err and unwraps to it. If err
+// already carries a stack, or is nil, StackErr returns err unchanged.
+func StackErr(err error) error {
+	if err == nil || hasStack(err) {
+		return err
+	}
+	return &stackError{err: err, stack: callers()}
+}
+
+// Errorf is fmt.Errorf, recording the stack of its caller like StackErr
+// unless an error wrapped with %w already carries one.
+func Errorf(format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	if hasStack(err) {
+		return err
+	}
+	return &stackError{err: err, stack: callers()}
+}
+
+type stackError struct {
+	err   error
+	stack errorStack
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+
+func (e *stackError) Unwrap() error { return e.err }
+
+func hasStack(err error) bool {
+	var se *stackError
+	return errors.As(err, &se)
+}
+
+// maxStackDepth bounds the frames recorded by StackErr and Errorf.
+const maxStackDepth = 32
+
+// callers records the stack of the caller of its caller.
+func callers() errorStack {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(3, pcs[:]) // skip runtime.Callers, callers and StackErr/Errorf
+	return errorStack(pcs[:n:n])
+}
+
+type errorStack []uintptr
+
+func (s errorStack) frames(visit func(runtime.Frame)) {
+	frames := runtime.CallersFrames(s)
+	for {
+		f, more := frames.Next()
+		if f.Function != "runtime.goexit" {
+			visit(f)
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+func (s errorStack) MarshalLogArray(arr zapcore.ArrayEncoder) error {
+	s.frames(func(f runtime.Frame) {
+		arr.AppendString(fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line))
+	})
+	return nil
+}
+
+// text renders s like zap's stacktraces, indented below a heading.
+func (s errorStack) text(heading string) string {
+	var b strings.Builder
+	b.WriteString(heading)
+	b.WriteByte(':')
+	s.frames(func(f runtime.Frame) {
+		fmt.Fprintf(&b, "\n\t%s\n\t\t%s:%d", f.Function, f.File, f.Line)
+	})
+	return b.String()
+}
+
+type errorMarshaler struct {
+	key       string
+	err       error
+	omitStack bool // printed by the console encoder instead
+}
+
+// consoleStack moves the error's stack out of the fields, for the console
+// encoder to print it below the entry. An error whose methods panic, such as
+// a nil pointer, is left to MarshalLogObject to report.
+func (e errorMarshaler) consoleStack() (f Field, stack string) {
+	defer func() {
+		if recover() != nil {
+			f, stack = Field{Key: e.key, Type: zapcore.InlineMarshalerType, Interface: e}, ""
+		}
+	}()
+	var w errorWalk
+	w.walk(e.err, "", 0)
+	if w.stack == nil {
+		return Field{Key: e.key, Type: zapcore.InlineMarshalerType, Interface: e}, ""
+	}
+	e.omitStack = true
+	return Field{Key: e.key, Type: zapcore.InlineMarshalerType, Interface: e}, w.stack.text(e.key + "Stack")
+}
+
+func (e errorMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) (retErr error) {
+	// Like zap, report a nil pointer whose Error method panics as "<nil>".
+	defer func() {
+		if rerr := recover(); rerr != nil {
+			if v := reflect.ValueOf(e.err); v.Kind() == reflect.Pointer && v.IsNil() {
+				enc.AddString(e.key, "<nil>")
+				return
+			}
+			retErr = fmt.Errorf("PANIC=%v", rerr)
+		}
+	}()
+
+	basic := e.err.Error()
+	enc.AddString(e.key, basic)
+	if f, ok := e.err.(fmt.Formatter); ok {
+		if verbose := fmt.Sprintf("%+v", f); verbose != basic {
+			enc.AddString(e.key+"Verbose", verbose)
+		}
+	}
+
+	if class, ok := ClassifyError(e.err); ok {
+		if class.Code != "" {
+			enc.AddString(e.key+".code", class.Code)
+		}
+		if class.Kind != "" {
+			enc.AddString(e.key+".kind", string(class.Kind))
+		}
+		enc.AddBool(e.key+".retryable", class.Retryable)
+	}
+
+	var w errorWalk
+	w.walk(e.err, basic, 0)
+	for _, f := range w.fields {
+		f.AddTo(enc)
+	}
+	if len(w.causes) > 0 {
+		if err := enc.AddArray(e.key+"Causes", causeArray(w.causes)); err != nil {
+			return err
+		}
+	}
+	if w.stack != nil && !e.omitStack {
+		return enc.AddArray(e.key+"Stack", w.stack)
+	}
+	return nil
+}
+
+// maxErrorDepth bounds the walk, in case of cyclic or absurdly deep chains.
+const maxErrorDepth = 64
+
+// errorWalk collects the attached fields, distinct cause messages and
+// innermost stack of an error tree, depth first.
+type errorWalk struct {
+	fields     []Field
+	keys       map[string]bool
+	causes     []string
+	seen       map[string]bool
+	stack      errorStack
+	stackDepth int
+}
+
+func (w *errorWalk) walk(err error, parentMsg string, depth int) {
+	if depth > maxErrorDepth {
+		return
+	}
+	msg := parentMsg
+	if depth > 0 {
+		msg = err.Error()
+		if msg != parentMsg && !w.seen[msg] {
+			if w.seen == nil {
+				w.seen = make(map[string]bool)
+			}
+			w.seen[msg] = true
+			w.causes = append(w.causes, msg)
+		}
+	}
+	switch e := err.(type) {
+	case *fieldError:
+		w.addFields(e.fields)
+	case *stackError:
+		if w.stack == nil || depth > w.stackDepth {
+			w.stack, w.stackDepth = e.stack, depth
+		}
+	}
+	for _, inner := range unwrapErrors(err) {
+		if inner != nil {
+			w.walk(inner, msg, depth+1)
+		}
+	}
+}
+
+func (w *errorWalk) addFields(fields []Field) {
+	for _, f := range fields {
+		if f.Key != "" {
+			if w.keys[f.Key] {
+				continue
+			}
+			if w.keys == nil {
+				w.keys = make(map[string]bool)
+			}
+			w.keys[f.Key] = true
+		}
+		w.fields = append(w.fields, f)
+	}
+}
+
+// causeArray encodes messages in the shape of zap's errorCauses.
+type causeArray []string
+
+func (a causeArray) MarshalLogArray(arr zapcore.ArrayEncoder) error {
+	for _, msg := range a {
+		err := arr.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("error", msg)
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
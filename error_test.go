@@ -0,0 +1,135 @@
+package lad
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestWrapErr(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	read := WrapErr(fs.ErrNotExist, String("path", "/etc/app.yaml"), Int("attempt", 1))
+	load := fmt.Errorf("load config: %w", WrapErr(read, Int("attempt", 3)))
+	joined := errors.Join(load, WrapErr(errors.New("dial db"), String("host", "db1")))
+	if !errors.Is(joined, fs.ErrNotExist) {
+		t.Fatal("errors.Is does not see through WrapErr")
+	}
+	if WrapErr(nil, String("k", "v")) != nil {
+		t.Fatal("WrapErr(nil) is not nil")
+	}
+	l.Error("startup failed", Error(joined))
+
+	got := out.String()
+	for _, want := range []string{
+		`"error":"load config: file does not exist\ndial db"`,
+		`"attempt":3`,
+		`"path":"/etc/app.yaml"`,
+		`"host":"db1"`,
+		`"errorCauses":[{"error":"load config: file does not exist"},{"error":"file does not exist"},{"error":"dial db"}]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %s does not contain %s", got, want)
+		}
+	}
+	if strings.Contains(got, `"attempt":1`) {
+		t.Errorf("output %s has the inner attempt field", got)
+	}
+}
+
+func TestErrorNilPointer(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	var pe *fs.PathError
+	l.Error("nil", NamedError("err", pe))
+	if !strings.Contains(out.String(), `"err":"<nil>"`) {
+		t.Fatalf("output %s", out.String())
+	}
+}
+
+func TestErrorNilPointerConsole(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out, Encoding: ConsoleEncoding}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	var pe *fs.PathError
+	l.Error("nil", NamedError("err", pe))
+	if !strings.Contains(out.String(), `{"err": "<nil>"}`) {
+		t.Fatalf("output %s", out.String())
+	}
+}
+
+func openConfig() error {
+	return StackErr(fs.ErrNotExist)
+}
+
+func TestStackErr(t *testing.T) {
+	var jsonOut, consoleOut bytes.Buffer
+	l, err := New(
+		WithWriter(WriterConfig{Writer: &jsonOut}),
+		WithWriter(WriterConfig{Writer: &consoleOut, Encoding: ConsoleEncoding}),
+	)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	inner := openConfig()
+	outer := Errorf("load: %w", inner)
+	if !errors.Is(outer, fs.ErrNotExist) || outer.Error() != "load: file does not exist" {
+		t.Fatalf("outer=%v", outer)
+	}
+	if StackErr(outer) != outer {
+		t.Fatal("StackErr recorded a second stack")
+	}
+	l.Error("failed", Error(outer))
+
+	var entry struct {
+		Stack  []string            `json:"errorStack"`
+		Causes []map[string]string `json:"errorCauses"`
+	}
+	if err := json.Unmarshal(jsonOut.Bytes(), &entry); err != nil {
+		t.Fatalf("JSON output %q: %v", jsonOut.String(), err)
+	}
+	if len(entry.Stack) == 0 || !strings.Contains(entry.Stack[0], "lad.openConfig (") {
+		t.Fatalf("errorStack=%q, want it to start at openConfig", entry.Stack)
+	}
+	if len(entry.Causes) != 1 {
+		t.Fatalf("errorCauses=%v", entry.Causes)
+	}
+
+	got := consoleOut.String()
+	if strings.Contains(got, `"errorStack"`) {
+		t.Fatalf("console output has the stack inline: %s", got)
+	}
+	if !strings.Contains(got, "\nerrorStack:\n\tgithub.com/omivix/lad.openConfig\n\t\t") {
+		t.Fatalf("console output %s", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	wrapped := fmt.Errorf("open: %w", WrapErr(fs.ErrNotExist, String("path", "/tmp/x")))
+	l.Error("batch failed", Errors("errors", []error{wrapped, nil, errors.New("plain")}))
+
+	want := `"errors":[{"error":"open: file does not exist","error.kind":"not_found","error.retryable":false,` +
+		`"path":"/tmp/x","errorCauses":[{"error":"file does not exist"}]},{"error":"plain"}]`
+	if got := out.String(); !strings.Contains(got, want) {
+		t.Errorf("output %s does not contain %s", got, want)
+	}
+}
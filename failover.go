@@ -0,0 +1,176 @@
+package lad
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// FailoverConfig controls an output that falls back to a secondary output
+// while its primary fails.
+type FailoverConfig struct {
+	// Primary and Secondary hold output options (WithFile, WithConsole,
+	// WithWriter, ...), e.g. a file and a console writing to os.Stderr.
+	Primary   []Option
+	Secondary []Option
+
+	MaxFailures   int           // Consecutive failed writes before switching. Defaults to 3.
+	ProbeInterval time.Duration // How often the primary is retried after switching. Defaults to 30s.
+}
+
+// WithFailover adds an output that writes to the primary outputs and switches
+// to the secondary ones after MaxFailures consecutive write errors (a
+// read-only file system, a full disk, ...). Entries whose primary write fails
+// are written to the secondary outputs instead, so none are lost.
+//
+// On switching, a warning is written to the secondary outputs. Every
+// ProbeInterval the next entry is tried on the primary again; once that
+// succeeds, logging switches back.
+func WithFailover(fc FailoverConfig) Option {
+	return func(c *config) error {
+		primary, err := outputBuilders("failover primary", fc.Primary)
+		if err != nil {
+			return err
+		}
+		secondary, err := outputBuilders("failover secondary", fc.Secondary)
+		if err != nil {
+			return err
+		}
+		c.coreBuilders = append(c.coreBuilders, func(cfg *config) (zapcore.Core, error) {
+			p, err := buildTee(cfg, primary)
+			if err != nil {
+				return nil, err
+			}
+			s, err := buildTee(cfg, secondary)
+			if err != nil {
+				return nil, err
+			}
+			return &failoverCore{
+				primary:   p,
+				secondary: s,
+				state: &failoverState{
+					maxFailures: orDefaultInt(fc.MaxFailures, 3),
+					probe:       orDefaultDuration(fc.ProbeInterval, 30*time.Second),
+				},
+			}, nil
+		})
+		return nil
+	}
+}
+
+// failoverState is shared by a failoverCore and its With clones.
+type failoverState struct {
+	maxFailures int
+	probe       time.Duration
+
+	mu        sync.Mutex
+	failures  int       // consecutive primary write errors
+	failed    bool      // writing to the secondary
+	nextProbe time.Time // when to try the primary again
+}
+
+type failoverCore struct {
+	primary   zapcore.Core
+	secondary zapcore.Core
+	state     *failoverState
+}
+
+func (fc *failoverCore) Enabled(l zapcore.Level) bool {
+	return fc.primary.Enabled(l) || fc.secondary.Enabled(l)
+}
+
+func (fc *failoverCore) With(fields []Field) zapcore.Core {
+	return &failoverCore{
+		primary:   fc.primary.With(fields),
+		secondary: fc.secondary.With(fields),
+		state:     fc.state,
+	}
+}
+
+func (fc *failoverCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if fc.Enabled(ent.Level) {
+		return ce.AddCore(ent, fc)
+	}
+	return ce
+}
+
+func (fc *failoverCore) Write(ent zapcore.Entry, fields []Field) error {
+	st := fc.state
+	st.mu.Lock()
+	usePrimary := !st.failed || !ent.Time.Before(st.nextProbe)
+	st.mu.Unlock()
+
+	if usePrimary && fc.primary.Enabled(ent.Level) {
+		err := writeChecked(fc.primary, ent, fields)
+		if fc.record(err) {
+			return nil
+		}
+	}
+	if st.active() && !fc.primary.Enabled(ent.Level) {
+		return nil
+	}
+	return writeChecked(fc.secondary, ent, fields)
+}
+
+// record updates the state after a primary write and reports whether the
+// write succeeded. It emits the switch-over and recovery notices.
+func (fc *failoverCore) record(err error) bool {
+	st := fc.state
+	st.mu.Lock()
+	if err == nil {
+		recovered := st.failed
+		st.failures, st.failed = 0, false
+		st.mu.Unlock()
+		if recovered {
+			fc.notice(fc.primary, zapcore.InfoLevel, "lad: primary output recovered; switched back")
+		}
+		return true
+	}
+
+	st.failures++
+	failures := st.failures
+	switched := !st.failed && st.failures >= st.maxFailures
+	if switched {
+		st.failed = true
+	}
+	if st.failed {
+		st.nextProbe = time.Now().Add(st.probe)
+	}
+	st.mu.Unlock()
+	if switched {
+		fc.notice(fc.secondary, zapcore.WarnLevel, "lad: primary output failing; switched to secondary",
+			Error(err), Int("failures", failures), Duration("probeInterval", st.probe))
+	}
+	return false
+}
+
+// active reports whether the primary is in use.
+func (st *failoverState) active() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return !st.failed
+}
+
+// notice writes a message of lad itself, bypassing the core's level so that
+// switches are always recorded.
+func (fc *failoverCore) notice(core zapcore.Core, level zapcore.Level, msg string, fields ...Field) {
+	_ = core.Write(zapcore.Entry{
+		Level:      level,
+		Time:       time.Now(),
+		LoggerName: "lad",
+		Message:    msg,
+	}, fields)
+}
+
+// Sync flushes both outputs. Errors of a failed primary are not reported.
+func (fc *failoverCore) Sync() error {
+	errs := []error{fc.secondary.Sync()}
+	if fc.state.active() {
+		errs = append(errs, fc.primary.Sync())
+	} else {
+		_ = fc.primary.Sync()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+package lad
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyWriter fails every write while broken is set.
+type flakyWriter struct {
+	broken atomic.Bool
+	writes atomic.Int64
+	mu     sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	w.writes.Add(1)
+	if w.broken.Load() {
+		return 0, errors.New("read-only file system")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *flakyWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestWithFailover(t *testing.T) {
+	primary, secondary := &flakyWriter{}, &flakyWriter{}
+	primary.broken.Store(true)
+
+	l, err := New(WithFailover(FailoverConfig{
+		Primary:       []Option{WithWriter(WriterConfig{Writer: primary})},
+		Secondary:     []Option{WithWriter(WriterConfig{Writer: secondary})},
+		MaxFailures:   2,
+		ProbeInterval: 50 * time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	l.Info("a")
+	l.Info("b") // second failure: switch
+	l.Info("c") // secondary only, no primary attempt
+	if got := primary.writes.Load(); got != 2 {
+		t.Fatalf("primary writes=%d, want 2", got)
+	}
+	out := secondary.String()
+	for _, want := range []string{`"msg":"a"`, `"msg":"b"`, `"msg":"c"`, "switched to secondary", "read-only file system"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("secondary=%q, want it to contain %q", out, want)
+		}
+	}
+
+	primary.broken.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	l.Info("d")
+	l.Info("e")
+	if got := primary.String(); !strings.Contains(got, `"msg":"d"`) || !strings.Contains(got, "recovered") || !strings.Contains(got, `"msg":"e"`) {
+		t.Fatalf("primary=%q", got)
+	}
+	if strings.Contains(secondary.String(), `"msg":"d"`) {
+		t.Fatal("entry written to the secondary after recovery")
+	}
+}
@@ -0,0 +1,194 @@
+package lad
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// FlightRecorderConfig controls a flight recorder (see WithFlightRecorder).
+type FlightRecorderConfig struct {
+	// Outputs holds the output options (WithFile, WithConsole, ...) that
+	// receive entries, with their own levels, e.g. a file at Info.
+	Outputs []Option
+
+	// Capture selects the entries that are buffered instead of dropped.
+	// Defaults to every level the outputs reject.
+	Capture zapcore.LevelEnabler
+	// Trigger selects the entries that flush the buffer to the outputs,
+	// e.g. zap.WarnLevel. Defaults to zapcore.ErrorLevel.
+	Trigger zapcore.LevelEnabler
+
+	BufferSize int // Entries kept per buffer; older ones are dropped. Defaults to 100.
+}
+
+// WithFlightRecorder adds outputs whose rejected entries (typically Debug)
+// are kept in a bounded buffer and written only when an entry accepted by
+// Trigger follows, giving an error the context that led to it. Buffered
+// entries are discarded otherwise.
+//
+// Each logger derived with With gets its own buffer, so a per-request logger
+// (logger.With(requestID)) records one request. To share a buffer across
+// loggers, attach one to a context with ContextWithFlight and pass
+// Flight(ctx) as a field, either to With or to a log call.
+func WithFlightRecorder(fc FlightRecorderConfig) Option {
+	return func(c *config) error {
+		builders, err := outputBuilders("flight recorder", fc.Outputs)
+		if err != nil {
+			return err
+		}
+		c.coreBuilders = append(c.coreBuilders, func(cfg *config) (zapcore.Core, error) {
+			inner, err := buildTee(cfg, builders)
+			if err != nil {
+				return nil, err
+			}
+			capture := fc.Capture
+			if capture == nil {
+				capture = LevelEnablerFunc(func(l zapcore.Level) bool { return !inner.Enabled(l) })
+			}
+			trigger := fc.Trigger
+			if trigger == nil {
+				trigger = zapcore.ErrorLevel
+			}
+			size := orDefaultInt(fc.BufferSize, 100)
+			return &flightCore{
+				inner:   inner,
+				capture: capture,
+				trigger: trigger,
+				size:    size,
+				buf:     newFlightBuffer(size),
+			}, nil
+		})
+		return nil
+	}
+}
+
+type flightContextKey struct{}
+
+// ContextWithFlight returns a context carrying a new flight recorder buffer,
+// e.g. at the start of a request. Entries logged with Flight(ctx) share it.
+func ContextWithFlight(ctx context.Context) context.Context {
+	return context.WithValue(ctx, flightContextKey{}, &flightBuffer{})
+}
+
+// Flight returns a field that makes flight recorders buffer entries in the
+// buffer of ctx (see ContextWithFlight). It is not encoded, and is a no-op
+// when ctx carries no buffer.
+func Flight(ctx context.Context) Field {
+	buf, ok := ctx.Value(flightContextKey{}).(*flightBuffer)
+	if !ok {
+		return Skip()
+	}
+	return Field{Type: zapcore.SkipType, Interface: buf}
+}
+
+// flightBuffer holds entries until a trigger flushes them.
+type flightBuffer struct {
+	mu      sync.Mutex
+	size    int // 0 until first used by a core
+	entries []flightEntry
+}
+
+type flightEntry struct {
+	core   zapcore.Core // the With-derived outputs the entry belongs to
+	ent    zapcore.Entry
+	fields []Field
+}
+
+func newFlightBuffer(size int) *flightBuffer {
+	return &flightBuffer{size: size}
+}
+
+func (b *flightBuffer) add(size int, e flightEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.size == 0 {
+		b.size = size
+	}
+	if len(b.entries) >= b.size {
+		clear(b.entries[:1])
+		b.entries = b.entries[1:]
+	}
+	b.entries = append(b.entries, e)
+}
+
+func (b *flightBuffer) take() []flightEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries := b.entries
+	b.entries = nil
+	return entries
+}
+
+type flightCore struct {
+	inner   zapcore.Core
+	capture zapcore.LevelEnabler
+	trigger zapcore.LevelEnabler
+	size    int
+	buf     *flightBuffer
+	scoped  bool // buf comes from a context and is kept by With
+}
+
+func (fc *flightCore) Enabled(l zapcore.Level) bool {
+	return fc.inner.Enabled(l) || fc.capture.Enabled(l) || fc.trigger.Enabled(l)
+}
+
+func (fc *flightCore) With(fields []Field) zapcore.Core {
+	clone := *fc
+	clone.inner = fc.inner.With(fields)
+	if buf := flightBufferOf(fields); buf != nil {
+		clone.buf, clone.scoped = buf, true
+	} else if !fc.scoped {
+		clone.buf = newFlightBuffer(fc.size)
+	}
+	return &clone
+}
+
+func (fc *flightCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if fc.Enabled(ent.Level) {
+		return ce.AddCore(ent, fc)
+	}
+	return ce
+}
+
+func (fc *flightCore) Write(ent zapcore.Entry, fields []Field) error {
+	buf := flightBufferOf(fields)
+	if buf == nil {
+		buf = fc.buf
+	}
+
+	if fc.trigger.Enabled(ent.Level) {
+		var errs []error
+		for _, e := range buf.take() {
+			// Buffered entries bypass the output levels that rejected them.
+			errs = append(errs, e.core.Write(e.ent, e.fields))
+		}
+		errs = append(errs, writeChecked(fc.inner, ent, fields))
+		return errors.Join(errs...)
+	}
+	if fc.inner.Enabled(ent.Level) {
+		return writeChecked(fc.inner, ent, fields)
+	}
+	if fc.capture.Enabled(ent.Level) {
+		buf.add(fc.size, flightEntry{
+			core:   fc.inner,
+			ent:    ent,
+			fields: append([]Field(nil), fields...),
+		})
+	}
+	return nil
+}
+
+func (fc *flightCore) Sync() error { return fc.inner.Sync() }
+
+// flightBufferOf returns the last buffer attached with Flight in fields.
+func flightBufferOf(fields []Field) *flightBuffer {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if buf, ok := fields[i].Interface.(*flightBuffer); ok && fields[i].Type == zapcore.SkipType {
+			return buf
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package lad
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithFlightRecorder(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithFlightRecorder(FlightRecorderConfig{
+		Outputs:    []Option{WithWriter(WriterConfig{Level: zapcore.InfoLevel, Writer: &out})},
+		BufferSize: 2,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	req1 := l.With(String("req", "1"))
+	req2 := l.With(String("req", "2"))
+	req1.Debug("evicted")
+	req1.Debug("d1")
+	req2.Debug("other request")
+	req1.Debug("d2")
+	req1.Info("info")
+	req1.Error("boom")
+	req1.Error("after") // buffer was emptied by boom
+
+	assertLines(t, "out", out.String(), "info", "d1", "d2", "boom", "after")
+	if !strings.Contains(out.String(), `"msg":"d1","req":"1"`) {
+		t.Fatalf("buffered entry lost its context: %q", out.String())
+	}
+}
+
+func TestFlightContext(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithFlightRecorder(FlightRecorderConfig{
+		Outputs: []Option{WithWriter(WriterConfig{Level: zapcore.InfoLevel, Writer: &out})},
+		Trigger: zapcore.WarnLevel,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	ctx := ContextWithFlight(context.Background())
+	l.Named("http").Debug("request", Flight(ctx))
+	l.Named("db").With(Flight(ctx)).With(String("table", "users")).Debug("query")
+	l.Debug("unrelated")
+	l.Named("http").Warn("slow", Flight(ctx))
+
+	assertLines(t, "out", out.String(), "request", "query", "slow")
+}
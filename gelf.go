@@ -0,0 +1,374 @@
+package lad
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// GELFCompression selects how GELF messages are compressed over UDP.
+type GELFCompression string
+
+const (
+	// GELFGzip compresses UDP messages with gzip (default).
+	GELFGzip GELFCompression = "gzip"
+	// GELFZlib compresses UDP messages with zlib.
+	GELFZlib GELFCompression = "zlib"
+	// GELFNoCompression sends UDP messages uncompressed.
+	GELFNoCompression GELFCompression = "none"
+)
+
+// DefaultGELFChunkSize is the largest UDP datagram sent, chosen to fit a
+// typical 1500 byte MTU.
+const DefaultGELFChunkSize = 1420
+
+// GELFConfig controls output to a Graylog GELF input.
+type GELFConfig struct {
+	Level   zapcore.Level
+	Enabler zapcore.LevelEnabler // Overrides Level when set (see LevelRange).
+
+	Network string      // "udp" (default) or "tcp" (and their variants).
+	Address string      // e.g. "graylog:12201". Required.
+	TLS     *tls.Config // Optional; enables TLS over TCP.
+	Host    string      // The "host" field. Defaults to os.Hostname().
+
+	// Compression applies to UDP only; GELF TCP inputs take plain
+	// null-terminated messages. Defaults to GELFGzip.
+	Compression GELFCompression
+	// ChunkSize is the maximum UDP datagram size; larger messages are split
+	// into up to 128 chunks. Defaults to DefaultGELFChunkSize.
+	ChunkSize int
+
+	BufferBytes  int           // In-memory buffer while disconnected. Defaults to 4 MiB.
+	DialTimeout  time.Duration // Defaults to 5s; also used as the write deadline.
+	MinBackoff   time.Duration // First reconnect delay. Defaults to 100ms.
+	MaxBackoff   time.Duration // Reconnect delay cap. Defaults to 30s.
+	FlushTimeout time.Duration // How long Sync waits for the buffer to drain. Defaults to 5s.
+
+	SpoolDir   string // Optional; durable on-disk buffer (see NetworkConfig.SpoolDir).
+	SpoolBytes int    // Disk cap of SpoolDir. Defaults to 256 MiB.
+
+	Stats *SinkStats // Optional; receives delivery and drop counters.
+}
+
+// WithGELF adds a core sending GELF 1.1 messages to Graylog.
+//
+// The message becomes short_message, a stack trace full_message, and fields
+// are sent as "_"-prefixed additional fields (nested objects are flattened
+// with dots). Levels map to syslog severities. Like WithNetwork, messages are
+// buffered and sent from a background goroutine.
+func WithGELF(gc GELFConfig) Option {
+	return func(c *config) error {
+		c.coreBuilders = append(c.coreBuilders, func(cfg *config) (zapcore.Core, error) {
+			if gc.Address == "" {
+				return nil, errors.New("lad: GELFConfig.Address is required")
+			}
+			network := orDefault(gc.Network, "udp")
+			datagram := false
+			switch network {
+			case "tcp", "tcp4", "tcp6":
+			case "udp", "udp4", "udp6":
+				datagram = true
+			default:
+				return nil, fmt.Errorf("lad: unsupported GELF network %q", network)
+			}
+			if datagram && gc.TLS != nil {
+				return nil, fmt.Errorf("lad: TLS is not supported over %q", network)
+			}
+			compression := gc.Compression
+			switch compression {
+			case "":
+				compression = GELFGzip
+			case GELFGzip, GELFZlib, GELFNoCompression:
+			default:
+				return nil, fmt.Errorf("lad: unknown GELFCompression %q", compression)
+			}
+			chunkSize := orDefaultInt(gc.ChunkSize, DefaultGELFChunkSize)
+			if chunkSize <= gelfChunkHeader {
+				return nil, fmt.Errorf("lad: GELFConfig.ChunkSize %d is too small", chunkSize)
+			}
+
+			host := gc.Host
+			if host == "" {
+				host, _ = os.Hostname()
+			}
+
+			ns := &netSender{
+				network:  network,
+				address:  gc.Address,
+				tls:      gc.TLS,
+				timeout:  gc.DialTimeout,
+				datagram: datagram,
+				stats:    gc.Stats,
+			}
+			if ns.timeout <= 0 {
+				ns.timeout = 5 * time.Second
+			}
+			if ns.stats == nil {
+				ns.stats = &SinkStats{}
+			}
+			deliver := ns.deliver
+			if datagram {
+				gs := &gelfSender{net: ns, compression: compression, chunkSize: chunkSize}
+				deliver = gs.deliver
+			}
+			queue, err := newRecordQueue(gc.SpoolDir, gc.SpoolBytes, gc.BufferBytes)
+			if err != nil {
+				return nil, err
+			}
+			s := cfg.ship(&shipper{
+				deliver:      deliver,
+				closeSender:  ns.close,
+				queue:        queue,
+				flushTimeout: gc.FlushTimeout,
+				minBackoff:   gc.MinBackoff,
+				maxBackoff:   gc.MaxBackoff,
+				stats:        ns.stats,
+			})
+
+			core := &gelfCore{
+				LevelEnabler: levelEnabler(gc.Enabler, gc.Level),
+				host:         host,
+				caller:       cfg.callerEncode,
+				location:     cfg.location,
+				nullByte:     !datagram,
+				out:          s,
+			}
+			return core, nil
+		})
+		return nil
+	}
+}
+
+type gelfCore struct {
+	zapcore.LevelEnabler
+	host     string
+	caller   zapcore.CallerEncoder
+	location *time.Location // for time fields; nil keeps their zone
+	nullByte bool           // terminate messages with \0 (stream transports)
+	fields   []Field
+	out      *shipper
+}
+
+func (gc *gelfCore) With(fields []Field) zapcore.Core {
+	clone := *gc
+	clone.fields = append(gc.fields[:len(gc.fields):len(gc.fields)], fields...)
+	return &clone
+}
+
+func (gc *gelfCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if gc.Enabled(ent.Level) {
+		return ce.AddCore(ent, gc)
+	}
+	return ce
+}
+
+func (gc *gelfCore) Write(ent zapcore.Entry, fields []Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range gc.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	msg := make(map[string]any, len(enc.Fields)+8)
+	gelfFields("", enc.Fields, gc.location, msg)
+	msg["version"] = "1.1"
+	msg["host"] = gc.host
+	msg["short_message"] = ent.Message
+	msg["timestamp"] = json.Number(strconv.FormatFloat(float64(ent.Time.UnixMicro())/1e6, 'f', 6, 64))
+	msg["level"] = syslogSeverity(ent.Level)
+	if ent.Stack != "" {
+		msg["full_message"] = ent.Stack
+	}
+	if ent.LoggerName != "" {
+		msg["_logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined && gc.caller != nil {
+		arr := &stringArrayEncoder{}
+		gc.caller(ent.Caller, arr)
+		msg["_caller"] = strings.Join(arr.elems, "")
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if gc.nullByte {
+		data = append(data, 0)
+	}
+	if _, err := gc.out.Write(data); err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		return gc.out.Sync()
+	}
+	return nil
+}
+
+func (gc *gelfCore) Sync() error { return gc.out.Sync() }
+
+// gelfFields adds fields to msg as additional fields. GELF values must be
+// strings or numbers, so booleans and arrays are rendered as strings. Keys
+// are limited to [A-Za-z0-9_.-]; "_id" is reserved by Graylog and is sent as
+// "_id_". Times are shown in loc, when set.
+func gelfFields(prefix string, fields map[string]any, loc *time.Location, msg map[string]any) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		name := "_" + gelfKey(key)
+		if name == "_id" {
+			name = "_id_"
+		}
+		val := fields[k]
+		if p, ok := val.(plainValuer); ok {
+			val = p.plainValue()
+		}
+		switch v := val.(type) {
+		case map[string]any:
+			gelfFields(key, v, loc, msg)
+		case string:
+			msg[name] = v
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, float32, float64:
+			msg[name] = v
+		case time.Duration:
+			msg[name] = v.Seconds()
+		case time.Time:
+			if loc != nil {
+				v = v.In(loc)
+			}
+			msg[name] = v.Format(time.RFC3339Nano)
+		case []any:
+			data, err := json.Marshal(v)
+			if err != nil {
+				data = []byte(fmt.Sprint(v))
+			}
+			msg[name] = string(data)
+		default:
+			msg[name] = fmt.Sprint(v)
+		}
+	}
+}
+
+func gelfKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, k)
+}
+
+const (
+	gelfChunkHeader = 12 // magic (2) + message ID (8) + sequence (1) + count (1)
+	gelfMaxChunks   = 128
+)
+
+// gelfSender compresses and chunks UDP messages before handing them to the
+// socket; it is only used from the shipper goroutine.
+type gelfSender struct {
+	net         *netSender
+	compression GELFCompression
+	chunkSize   int
+}
+
+func (gs *gelfSender) deliver(batch [][]byte) error {
+	var (
+		datagrams [][]byte
+		tooLarge  [][]byte
+	)
+	for _, rec := range batch {
+		data, err := gs.compress(rec)
+		if err != nil {
+			return &permanentError{err: fmt.Errorf("lad: compress GELF message: %w", err)}
+		}
+		chunks := gelfChunks(data, gs.chunkSize, rand.Uint64())
+		if chunks == nil {
+			tooLarge = append(tooLarge, rec)
+			continue
+		}
+		datagrams = append(datagrams, chunks...)
+	}
+	if len(datagrams) > 0 {
+		if err := gs.net.deliver(datagrams); err != nil {
+			return err
+		}
+	}
+	if len(tooLarge) > 0 {
+		return &partialError{
+			err:      fmt.Errorf("lad: %d GELF messages exceed %d chunks", len(tooLarge), gelfMaxChunks),
+			rejected: tooLarge,
+		}
+	}
+	return nil
+}
+
+func (gs *gelfSender) compress(rec []byte) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		zw  interface {
+			Write([]byte) (int, error)
+			Close() error
+		}
+	)
+	switch gs.compression {
+	case GELFGzip:
+		zw = gzip.NewWriter(&buf)
+	case GELFZlib:
+		zw = zlib.NewWriter(&buf)
+	default:
+		return rec, nil
+	}
+	_, _ = zw.Write(rec)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gelfChunks splits data into GELF chunks of at most size bytes. It returns
+// data itself when it fits into one datagram, and nil when more than 128
+// chunks would be needed.
+func gelfChunks(data []byte, size int, id uint64) [][]byte {
+	if len(data) <= size {
+		return [][]byte{data}
+	}
+	payload := size - gelfChunkHeader
+	count := (len(data) + payload - 1) / payload
+	if count > gelfMaxChunks {
+		return nil
+	}
+	chunks := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		part := data[seq*payload : min((seq+1)*payload, len(data))]
+		chunk := make([]byte, 0, gelfChunkHeader+len(part))
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = binary.BigEndian.AppendUint64(chunk, id)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunks = append(chunks, append(chunk, part...))
+	}
+	return chunks
+}
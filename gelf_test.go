@@ -0,0 +1,182 @@
+package lad
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithGELFUDPGzip(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+
+	l, err := New(WithGELF(GELFConfig{Address: pc.LocalAddr().String(), Host: "host1"}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Named("api").Warn("slow request", Int("ms", 1200), Bool("ok", false), String("id", "x1"),
+		Dict("req", String("path", "/v1")))
+	if err := l.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	buf := make([]byte, 65536)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(buf[:n]))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	data, _ := io.ReadAll(zr)
+
+	var msg map[string]any
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("decode %q: %v", data, err)
+	}
+	want := map[string]any{
+		"version":       "1.1",
+		"host":          "host1",
+		"short_message": "slow request",
+		"level":         float64(4),
+		"_logger":       "api",
+		"_ms":           float64(1200),
+		"_ok":           "false",
+		"_id_":          "x1",
+		"_req.path":     "/v1",
+	}
+	for k, v := range want {
+		if msg[k] != v {
+			t.Fatalf("%s=%v, want %v (msg=%s)", k, msg[k], v, data)
+		}
+	}
+	if _, ok := msg["timestamp"].(float64); !ok {
+		t.Fatalf("timestamp=%v", msg["timestamp"])
+	}
+}
+
+func TestWithGELFUDPChunks(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+
+	l, err := New(WithGELF(GELFConfig{
+		Address:     pc.LocalAddr().String(),
+		Compression: GELFNoCompression,
+		ChunkSize:   200,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	long := strings.Repeat("x", 1000)
+	l.Info("big", String("payload", long))
+	if err := l.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	parts := map[byte][]byte{}
+	var count byte
+	buf := make([]byte, 65536)
+	for count == 0 || len(parts) < int(count) {
+		_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("read: %v (got %d chunks)", err, len(parts))
+		}
+		if n > 200 || buf[0] != 0x1e || buf[1] != 0x0f {
+			t.Fatalf("bad chunk of %d bytes: % x", n, buf[:min(n, 12)])
+		}
+		count = buf[11]
+		parts[buf[10]] = append([]byte(nil), buf[12:n]...)
+	}
+	var data []byte
+	for seq := byte(0); seq < count; seq++ {
+		data = append(data, parts[seq]...)
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("decode reassembled message: %v", err)
+	}
+	if msg["_payload"] != long {
+		t.Fatalf("payload has %d bytes", len(msg["_payload"].(string)))
+	}
+}
+
+func TestWithGELFTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	msgs := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			msgs <- strings.TrimSuffix(msg, "\x00")
+		}
+	}()
+
+	l, err := New(WithGELF(GELFConfig{Network: "tcp", Address: ln.Addr().String()}), WithStacktrace(zapcore.InfoLevel))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Error("boom")
+	l.Info("after")
+	if err := l.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	for _, want := range []string{"boom", "after"} {
+		select {
+		case raw := <-msgs:
+			var msg map[string]any
+			if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+				t.Fatalf("decode %q: %v", raw, err)
+			}
+			if msg["short_message"] != want {
+				t.Fatalf("short_message=%v, want %q", msg["short_message"], want)
+			}
+			if full, _ := msg["full_message"].(string); !strings.Contains(full, "TestWithGELFTCP") {
+				t.Fatalf("full_message=%q, want a stack trace", full)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+}
+
+func TestGELFTimeFieldsUseLocation(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := map[string]any{}
+	gelfFields("", map[string]any{"at": at, "nested": map[string]any{"at": at}}, time.FixedZone("UTC+9", 9*3600), msg)
+	for _, key := range []string{"_at", "_nested.at"} {
+		if msg[key] != "2024-01-02T12:04:05+09:00" {
+			t.Errorf("%s=%v, want the time in the configured zone", key, msg[key])
+		}
+	}
+}
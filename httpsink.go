@@ -0,0 +1,374 @@
+package lad
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// HTTPSinkMode selects the request body format of an HTTP sink.
+type HTTPSinkMode string
+
+const (
+	// JSONArrayMode posts each batch as a JSON array of entries (default).
+	JSONArrayMode HTTPSinkMode = "json"
+	// LokiMode posts batches to a Grafana Loki push API endpoint
+	// (/loki/api/v1/push), grouping entries into streams by label set.
+	LokiMode HTTPSinkMode = "loki"
+)
+
+// HTTPSinkConfig controls batched HTTP push output.
+type HTTPSinkConfig struct {
+	Level   zapcore.Level
+	Enabler zapcore.LevelEnabler // Overrides Level when set (see LevelRange).
+
+	URL         string       // Required.
+	Mode        HTTPSinkMode // Defaults to JSONArrayMode.
+	Header      http.Header  // Extra request headers (auth, tenant ID, ...).
+	Client      *http.Client // Defaults to a client with a 10s timeout.
+	DisableGzip bool         // Send uncompressed bodies.
+
+	// LokiLabels lists field keys promoted to Loki stream labels. The level
+	// is always a label; StaticLabels are added to every stream. Characters
+	// Loki doesn't allow in label names are replaced with '_', so "user.id"
+	// becomes the label user_id.
+	LokiLabels   []string
+	StaticLabels map[string]string
+
+	TimeFormat string        // Defaults to DefaultTimeFormat when empty.
+	Encoder    EncoderConfig // Key names and value formats; zero value keeps defaults.
+
+	BatchSize     int           // Max entries per request. Defaults to 500.
+	BatchBytes    int           // Max encoded bytes per request. Defaults to 1 MiB.
+	FlushInterval time.Duration // Max time an entry waits for its batch. Defaults to 1s.
+	BufferBytes   int           // In-memory buffer. Defaults to 4 MiB.
+	MaxRetries    int           // Attempts after the first failure. Defaults to 5.
+	MinBackoff    time.Duration // First retry delay. Defaults to 100ms.
+	MaxBackoff    time.Duration // Retry delay cap. Defaults to 30s.
+	FlushTimeout  time.Duration // How long Sync waits for the buffer to drain. Defaults to 5s.
+
+	// DeadLetterFile receives, as JSON lines, the entries of batches that
+	// were rejected or still failed after MaxRetries.
+	DeadLetterFile string
+
+	SpoolDir   string // Optional; durable on-disk buffer (see NetworkConfig.SpoolDir).
+	SpoolBytes int    // Disk cap of SpoolDir. Defaults to 256 MiB.
+
+	Stats *SinkStats // Optional; receives delivery and drop counters.
+}
+
+// WithHTTPSink adds a core that batches entries by size and time, gzips them
+// and POSTs them to an HTTP endpoint from a background goroutine. Failed
+// requests are retried with exponential backoff; 4xx responses other than 408
+// and 429 are not retried.
+func WithHTTPSink(hc HTTPSinkConfig) Option {
+	return func(c *config) error {
+		c.coreBuilders = append(c.coreBuilders, func(cfg *config) (zapcore.Core, error) {
+			if strings.TrimSpace(hc.URL) == "" {
+				return nil, errors.New("lad: HTTPSinkConfig.URL is required")
+			}
+			mode := hc.Mode
+			switch mode {
+			case "":
+				mode = JSONArrayMode
+			case JSONArrayMode, LokiMode:
+			default:
+				return nil, fmt.Errorf("lad: unknown HTTPSinkMode %q", mode)
+			}
+
+			encCfg, err := cfg.encoderConfig(hc.Encoder, hc.TimeFormat, false)
+			if err != nil {
+				return nil, err
+			}
+			encCfg.LineEnding = "\n"
+
+			hs := &httpSender{
+				url:        hc.URL,
+				mode:       mode,
+				header:     hc.Header,
+				client:     hc.Client,
+				gzip:       !hc.DisableGzip,
+				deadLetter: hc.DeadLetterFile,
+			}
+			var closeSender func() error
+			if hs.client == nil {
+				hs.client = &http.Client{Timeout: 10 * time.Second}
+				closeSender = closeIdleConns(hs.client)
+			}
+
+			queue, err := newRecordQueue(hc.SpoolDir, hc.SpoolBytes, hc.BufferBytes)
+			if err != nil {
+				return nil, err
+			}
+			s := cfg.ship(&shipper{
+				deliver:       hs.deliver,
+				closeSender:   closeSender,
+				queue:         queue,
+				maxBatch:      orDefaultInt(hc.BatchSize, 500),
+				maxBatchBytes: orDefaultInt(hc.BatchBytes, 1<<20),
+				flushInterval: orDefaultDuration(hc.FlushInterval, time.Second),
+				flushTimeout:  hc.FlushTimeout,
+				minBackoff:    hc.MinBackoff,
+				maxBackoff:    hc.MaxBackoff,
+				maxRetries:    orDefaultInt(hc.MaxRetries, 5),
+				giveUp:        hs.giveUp,
+				stats:         hc.Stats,
+			})
+
+			core := &recordCore{
+				LevelEnabler: levelEnabler(hc.Enabler, hc.Level),
+				enc:          zapcore.NewJSONEncoder(encCfg),
+				record:       copyLine,
+				out:          s,
+			}
+			if mode == LokiMode {
+				core.record = lokiRecorder(hc.LokiLabels, hc.StaticLabels)
+			}
+			return core, nil
+		})
+		return nil
+	}
+}
+
+// closeIdleConns releases the connections of a client the output created.
+func closeIdleConns(c *http.Client) func() error {
+	return func() error {
+		c.CloseIdleConnections()
+		return nil
+	}
+}
+
+func copyLine(_ zapcore.Entry, _ []Field, line []byte) ([]byte, error) {
+	return line, nil
+}
+
+// lokiRecord is the queued form of an entry in LokiMode.
+type lokiRecord struct {
+	Time   string            `json:"t"`
+	Labels map[string]string `json:"l"`
+	Line   string            `json:"line"`
+}
+
+func lokiRecorder(keys []string, static map[string]string) func(zapcore.Entry, []Field, []byte) ([]byte, error) {
+	return func(ent zapcore.Entry, fields []Field, line []byte) ([]byte, error) {
+		labels := make(map[string]string, len(static)+len(keys)+1)
+		for k, v := range static {
+			labels[lokiLabel(k)] = v
+		}
+		labels["level"] = ent.Level.String()
+		if len(keys) > 0 {
+			enc := zapcore.NewMapObjectEncoder()
+			for _, f := range fields {
+				f.AddTo(enc)
+			}
+			for _, k := range keys {
+				if v, ok := enc.Fields[k]; ok {
+					labels[lokiLabel(k)] = fmt.Sprint(v)
+				}
+			}
+		}
+		return json.Marshal(lokiRecord{
+			Time:   strconv.FormatInt(ent.Time.UnixNano(), 10),
+			Labels: labels,
+			Line:   string(bytes.TrimRight(line, "\n")),
+		})
+	}
+}
+
+// lokiLabel makes k a valid Loki label name, [a-zA-Z_][a-zA-Z0-9_]*.
+func lokiLabel(k string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, k)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// httpSender posts batches; it is only used from the shipper goroutine.
+type httpSender struct {
+	url        string
+	mode       HTTPSinkMode
+	header     http.Header
+	client     *http.Client
+	gzip       bool
+	deadLetter string
+}
+
+func (hs *httpSender) deliver(batch [][]byte) error {
+	body, err := hs.body(batch)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	_, err = postBody(hs.client, hs.url, hs.header, "application/json", body, hs.gzip)
+	return err
+}
+
+func (hs *httpSender) body(batch [][]byte) ([]byte, error) {
+	if hs.mode != LokiMode {
+		return joinJSONArray(batch), nil
+	}
+
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	var (
+		streams []*stream
+		byKey   = map[string]*stream{}
+	)
+	for _, raw := range batch {
+		var rec lokiRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return nil, fmt.Errorf("lad: decode queued loki entry: %w", err)
+		}
+		key := labelKey(rec.Labels)
+		st, ok := byKey[key]
+		if !ok {
+			st = &stream{Stream: rec.Labels}
+			byKey[key] = st
+			streams = append(streams, st)
+		}
+		st.Values = append(st.Values, [2]string{rec.Time, rec.Line})
+	}
+	return json.Marshal(map[string]any{"streams": streams})
+}
+
+// giveUp appends the entries of a failed batch to the dead-letter file.
+func (hs *httpSender) giveUp(batch [][]byte, _ error) {
+	if hs.deadLetter == "" {
+		return
+	}
+	var buf bytes.Buffer
+	for _, raw := range batch {
+		line := raw
+		if hs.mode == LokiMode {
+			var rec lokiRecord
+			if json.Unmarshal(raw, &rec) == nil {
+				line = []byte(rec.Line)
+			}
+		}
+		buf.Write(bytes.TrimRight(line, "\n"))
+		buf.WriteByte('\n')
+	}
+	_ = appendDeadLetter(hs.deadLetter, buf.Bytes())
+}
+
+// postBody POSTs body, optionally gzip-compressed, classifies the response
+// status and returns the response body of successful requests.
+func postBody(client *http.Client, url string, header http.Header, contentType string, body []byte, compress bool) ([]byte, error) {
+	if compress {
+		var zbuf bytes.Buffer
+		zw := gzip.NewWriter(&zbuf)
+		_, _ = zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return nil, &permanentError{err: err}
+		}
+		body = zbuf.Bytes()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &permanentError{err: fmt.Errorf("lad: build request: %w", err)}
+	}
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", contentType)
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("lad: post %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, fmt.Errorf("lad: post %s: read response: %w", url, err)
+	}
+	if resp.StatusCode/100 == 2 {
+		return respBody, nil
+	}
+	msg := bytes.TrimSpace(respBody)
+	if len(msg) > 512 {
+		msg = msg[:512]
+	}
+	err = fmt.Errorf("lad: post %s: %s: %s", url, resp.Status, msg)
+	if retryableStatus(resp.StatusCode) {
+		return nil, err
+	}
+	return nil, &permanentError{err: err}
+}
+
+func retryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+}
+
+func joinJSONArray(batch [][]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, raw := range batch {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(bytes.TrimRight(raw, "\n"))
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
+
+func labelKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte(0)
+		b.WriteString(labels[k])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func appendDeadLetter(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func orDefaultDuration(v, def time.Duration) time.Duration {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
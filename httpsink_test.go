@@ -0,0 +1,123 @@
+package lad
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithHTTPSinkLoki(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("Content-Encoding=%q, want gzip", r.Header.Get("Content-Encoding"))
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("gzip: %v", err)
+			return
+		}
+		var body map[string]any
+		if err := json.NewDecoder(zr).Decode(&body); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	l, err := New(WithHTTPSink(HTTPSinkConfig{
+		URL:           srv.URL,
+		Mode:          LokiMode,
+		LokiLabels:    []string{"tenant", "user.id"},
+		StaticLabels:  map[string]string{"app": "billing", "k8s.namespace": "prod"},
+		FlushInterval: time.Hour, // only Sync flushes
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Info("a", String("tenant", "acme"), String("user.id", "u1"))
+	l.Info("b", String("tenant", "acme"), String("user.id", "u1"))
+	l.Warn("c", String("tenant", "globex"))
+	if err := l.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Fatalf("got %d requests, want 1 batch", len(bodies))
+	}
+	streams := bodies[0]["streams"].([]any)
+	if len(streams) != 2 {
+		t.Fatalf("got %d streams, want 2: %v", len(streams), streams)
+	}
+	first := streams[0].(map[string]any)
+	labels := first["stream"].(map[string]any)
+	if labels["app"] != "billing" || labels["tenant"] != "acme" || labels["level"] != "info" ||
+		labels["user_id"] != "u1" || labels["k8s_namespace"] != "prod" {
+		t.Fatalf("labels=%v", labels)
+	}
+	for _, name := range []string{"2fa", ""} {
+		if got := lokiLabel(name); got != "_"+name {
+			t.Errorf("lokiLabel(%q)=%q, want %q", name, got, "_"+name)
+		}
+	}
+	if values := first["values"].([]any); len(values) != 2 {
+		t.Fatalf("values=%v, want 2 lines", values)
+	}
+}
+
+func TestWithHTTPSinkDeadLetter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = io.Copy(io.Discard, r.Body)
+		http.Error(w, "boom", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	deadLetter := filepath.Join(t.TempDir(), "dead.jsonl")
+	stats := &SinkStats{}
+	l, err := New(WithHTTPSink(HTTPSinkConfig{
+		URL:            srv.URL,
+		DisableGzip:    true,
+		MaxRetries:     2,
+		MinBackoff:     time.Millisecond,
+		DeadLetterFile: deadLetter,
+		Stats:          stats,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Error("lost", Int("n", 1))
+	_ = l.Sync()
+
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("calls=%d, want 3 (1 + 2 retries)", n)
+	}
+	data, err := os.ReadFile(deadLetter)
+	if err != nil {
+		t.Fatalf("read dead letter: %v", err)
+	}
+	if !strings.Contains(string(data), `"msg":"lost"`) {
+		t.Fatalf("dead letter=%q", data)
+	}
+	if stats.DroppedEntries() != 1 || stats.Failures() != 3 {
+		t.Fatalf("dropped=%d failures=%d", stats.DroppedEntries(), stats.Failures())
+	}
+}
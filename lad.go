@@ -11,9 +11,11 @@ package lad
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -44,6 +46,10 @@ type config struct {
 	coreBuilders []func(*config) (zapcore.Core, error)
 	zapOpts      []zap.Option
 	callerEncode zapcore.CallerEncoder
+	location     *time.Location
+	routes       []route
+	resource     map[string]string
+	closers      []io.Closer // remote outputs, stopped by Close
 }
 
 // WithZapOptions appends raw zap options to the logger being built.
@@ -98,12 +104,59 @@ func WithStacktrace(level zapcore.Level) Option {
 	}
 }
 
+// WithTimeZone renders entry timestamps, Time fields and rotated file names in
+// the named zone: "UTC", "Local", or an IANA name such as "Europe/Berlin".
+// Since rotated file names can only be in UTC or Local time, New rejects other
+// zones combined with WithFile.
+func WithTimeZone(name string) Option {
+	return func(c *config) error {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "":
+			return errors.New("lad: time zone cannot be empty")
+		case "UTC":
+			c.location = time.UTC
+		case "Local":
+			c.location = time.Local
+		default:
+			loc, err := time.LoadLocation(name)
+			if err != nil {
+				return fmt.Errorf("lad: load time zone %q: %w", name, err)
+			}
+			c.location = loc
+		}
+		return nil
+	}
+}
+
+// WithLocation is like WithTimeZone but takes an already loaded location.
+func WithLocation(loc *time.Location) Option {
+	return func(c *config) error {
+		if loc == nil {
+			return errors.New("lad: location cannot be nil")
+		}
+		c.location = loc
+		return nil
+	}
+}
+
 // ConsoleConfig controls console output.
 type ConsoleConfig struct {
 	Level      zapcore.Level
+	Enabler    zapcore.LevelEnabler // Overrides Level when set (see LevelRange).
 	Colored    bool
 	TimeFormat string   // Defaults to DefaultTimeFormat when empty.
 	Output     *os.File // Defaults to os.Stdout when nil.
+
+	// SplitOutput sends entries enabled by SplitLevel to ErrorOutput and the
+	// rest to Output, for container platforms that treat stderr as errors.
+	// SplitLevel defaults to zap.WarnLevel when nil, so Info stays on stdout.
+	// Level (or Enabler) still applies on top.
+	SplitOutput bool
+	SplitLevel  zapcore.LevelEnabler
+	ErrorOutput *os.File // Defaults to os.Stderr when nil.
+
+	Encoder EncoderConfig // Key names and value formats; zero value keeps defaults.
 }
 
 // WithConsole adds a console core to the logger.
@@ -115,20 +168,38 @@ func WithConsole(cc ConsoleConfig) Option {
 				out = os.Stdout
 			}
 
-			encCfg := zap.NewProductionEncoderConfig()
-			encCfg.EncodeTime = timeEncoder(orDefault(cc.TimeFormat, DefaultTimeFormat))
-			encCfg.EncodeCaller = cfg.callerEncode
+			encCfg, err := cfg.encoderConfig(cc.Encoder, cc.TimeFormat, cc.Colored)
+			if err != nil {
+				return nil, err
+			}
 
-			if cc.Colored {
-				encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
-			} else {
-				encCfg.EncodeLevel = zapcore.CapitalLevelEncoder
+			enc, err := newEncoder(ConsoleEncoding, encCfg)
+			if err != nil {
+				return nil, err
 			}
 
-			core := zapcore.NewCore(
-				zapcore.NewConsoleEncoder(encCfg),
-				zapcore.AddSync(out),
-				cc.Level,
+			enabler := levelEnabler(cc.Enabler, cc.Level)
+			if !cc.SplitOutput {
+				return zapcore.NewCore(enc, zapcore.AddSync(out), enabler), nil
+			}
+
+			errOut := cc.ErrorOutput
+			if errOut == nil {
+				errOut = os.Stderr
+			}
+			split := cc.SplitLevel
+			if split == nil {
+				split = zapcore.WarnLevel
+			}
+			low := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+				return enabler.Enabled(l) && !split.Enabled(l)
+			})
+			high := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+				return enabler.Enabled(l) && split.Enabled(l)
+			})
+			core := zapcore.NewTee(
+				zapcore.NewCore(enc, zapcore.AddSync(out), low),
+				zapcore.NewCore(enc.Clone(), zapcore.AddSync(errOut), high),
 			)
 			return core, nil
 		})
@@ -149,14 +220,16 @@ const (
 // FileConfig controls rotating file output (powered by lumberjack).
 type FileConfig struct {
 	Level      zapcore.Level
+	Enabler    zapcore.LevelEnabler // Overrides Level when set (see LevelRange).
 	Filename   string
 	MaxSizeMB  int
 	MaxBackups int
 	MaxAgeDays int
 	Compress   bool
 
-	Encoding   FileEncoding // Defaults to JSONEncoding when empty.
-	TimeFormat string       // Defaults to DefaultTimeFormat when empty.
+	Encoding   FileEncoding  // Defaults to JSONEncoding when empty.
+	TimeFormat string        // Defaults to DefaultTimeFormat when empty.
+	Encoder    EncoderConfig // Key names and value formats; zero value keeps defaults.
 }
 
 // WithFile adds a rotating file core to the logger.
@@ -172,38 +245,78 @@ func WithFile(fc FileConfig) Option {
 				maxSize = 100
 			}
 
-			encoding := fc.Encoding
-			if encoding == "" {
-				encoding = JSONEncoding
+			localTime, err := cfg.localRotationTime()
+			if err != nil {
+				return nil, err
 			}
-
 			hook := &lumberjack.Logger{
 				Filename:   fc.Filename,
 				MaxSize:    maxSize,
 				MaxBackups: fc.MaxBackups,
 				MaxAge:     fc.MaxAgeDays,
 				Compress:   fc.Compress,
+				LocalTime:  localTime,
+			}
+
+			encCfg, err := cfg.encoderConfig(fc.Encoder, fc.TimeFormat, false)
+			if err != nil {
+				return nil, err
 			}
 
-			encCfg := zap.NewProductionEncoderConfig()
-			encCfg.EncodeTime = timeEncoder(orDefault(fc.TimeFormat, DefaultTimeFormat))
-			encCfg.EncodeLevel = zapcore.CapitalLevelEncoder
-			encCfg.EncodeCaller = cfg.callerEncode
-
-			var enc zapcore.Encoder
-			switch encoding {
-			case JSONEncoding:
-				enc = zapcore.NewJSONEncoder(encCfg)
-			case ConsoleEncoding:
-				enc = zapcore.NewConsoleEncoder(encCfg)
-			default:
-				return nil, fmt.Errorf("lad: unknown FileEncoding %q", encoding)
+			enc, err := newEncoder(fc.Encoding, encCfg)
+			if err != nil {
+				return nil, err
 			}
 
 			core := zapcore.NewCore(
 				enc,
 				zapcore.AddSync(hook),
-				fc.Level,
+				levelEnabler(fc.Enabler, fc.Level),
+			)
+			return core, nil
+		})
+		return nil
+	}
+}
+
+// WriterConfig controls output to an arbitrary io.Writer.
+type WriterConfig struct {
+	Level   zapcore.Level
+	Enabler zapcore.LevelEnabler // Overrides Level when set (see LevelRange).
+	Writer  io.Writer            // Required. Sync is used when the writer implements it.
+
+	Encoding   FileEncoding  // Defaults to JSONEncoding when empty.
+	Colored    bool          // Colors levels like ConsoleConfig.Colored; ConsoleEncoding only.
+	TimeFormat string        // Defaults to DefaultTimeFormat when empty.
+	Encoder    EncoderConfig // Key names and value formats; zero value keeps defaults.
+}
+
+// WithWriter adds a core writing to any io.Writer (a bytes.Buffer, a pipe,
+// a network connection, ...). Writes are serialized, so the writer does not
+// need to be safe for concurrent use.
+func WithWriter(wc WriterConfig) Option {
+	return func(c *config) error {
+		c.coreBuilders = append(c.coreBuilders, func(cfg *config) (zapcore.Core, error) {
+			if wc.Writer == nil {
+				return nil, errors.New("lad: WriterConfig.Writer is required")
+			}
+
+			if wc.Colored && wc.Encoding != ConsoleEncoding {
+				return nil, errors.New("lad: WriterConfig.Colored requires ConsoleEncoding")
+			}
+			encCfg, err := cfg.encoderConfig(wc.Encoder, wc.TimeFormat, wc.Colored)
+			if err != nil {
+				return nil, err
+			}
+			enc, err := newEncoder(wc.Encoding, encCfg)
+			if err != nil {
+				return nil, err
+			}
+
+			core := zapcore.NewCore(
+				enc,
+				zapcore.Lock(zapcore.AddSync(wc.Writer)),
+				levelEnabler(wc.Enabler, wc.Level),
 			)
 			return core, nil
 		})
@@ -237,13 +350,20 @@ func New(opts ...Option) (*Logger, error) {
 	for _, build := range cfg.coreBuilders {
 		core, err := build(cfg)
 		if err != nil {
+			closeAll(cfg.closers)
 			return nil, err
 		}
 		cores = append(cores, core)
 	}
 
 	core := zapcore.NewTee(cores...)
-	return zap.New(core, cfg.zapOpts...), nil
+	if len(cfg.closers) == 0 {
+		return zap.New(core, cfg.zapOpts...), nil
+	}
+	group := &closeGroup{closers: cfg.closers}
+	l := zap.New(&closingCore{Core: core, group: group}, cfg.zapOpts...)
+	closeGroups.Store(l, group)
+	return l, nil
 }
 
 // MustNew is like New but panics on error.
@@ -304,6 +424,102 @@ func Sync(l *Logger) error {
 	return err
 }
 
+// Close flushes and stops the remote outputs of l (network, GELF, HTTP,
+// Elasticsearch, OTLP and syslog), closing their connections and spool files. Entries
+// logged afterwards are dropped by those outputs; local outputs keep working.
+// l must be the logger returned by New or one derived from it with With or
+// LazyWith, and closing any of them closes them all. Close a logger before
+// building another one with the same SpoolDir.
+func Close(l *Logger) error {
+	if l == nil {
+		return nil
+	}
+	syncErr := Sync(l)
+	group, ok := closeGroups.Load(l)
+	if !ok {
+		group = findCloseGroup(l.Core())
+	}
+	if group == nil {
+		return syncErr
+	}
+	closeGroups.Delete(l)
+	return errors.Join(syncErr, group.(*closeGroup).close())
+}
+
+// closeGroups maps loggers returned by New to their remote outputs, which
+// options such as zap.Hooks may hide behind their own cores.
+var closeGroups sync.Map // *Logger -> *closeGroup
+
+func findCloseGroup(core zapcore.Core) any {
+	for {
+		switch c := core.(type) {
+		case *closingCore:
+			return c.group
+		case *lazyWithCore:
+			core = c.orig
+		default:
+			return nil
+		}
+	}
+}
+
+type closeGroup struct {
+	once    sync.Once
+	closers []io.Closer
+	err     error
+}
+
+func (g *closeGroup) close() error {
+	g.once.Do(func() { g.err = closeAll(g.closers) })
+	return g.err
+}
+
+func closeAll(closers []io.Closer) error {
+	var errs []error
+	for _, c := range closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// closingCore carries the remote outputs of a logger into its children, so
+// Close works on any of them.
+type closingCore struct {
+	zapcore.Core
+	group *closeGroup
+}
+
+func (c *closingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &closingCore{Core: c.Core.With(fields), group: c.group}
+}
+
+// localRotationTime reports whether rotated file names should use local time.
+// lumberjack only names them in UTC or the process local zone, so other zones
+// are rejected rather than silently naming files in a zone the entries don't
+// use.
+func (c *config) localRotationTime() (bool, error) {
+	switch c.location {
+	case time.Local:
+		return true, nil
+	case nil, time.UTC:
+		return false, nil
+	}
+	return false, fmt.Errorf("lad: rotated file names can only use UTC or Local time, not %q; "+
+		"set TZ=%[1]s and WithTimeZone(\"Local\") instead", c.location)
+}
+
+// newEncoder builds the encoder for encoding, defaulting to JSONEncoding.
+func newEncoder(encoding FileEncoding, encCfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	switch encoding {
+	case "", JSONEncoding:
+		return zapcore.NewJSONEncoder(encCfg), nil
+	case ConsoleEncoding:
+		return consoleEncoder{zapcore.NewConsoleEncoder(encCfg), encCfg.StacktraceKey != ""}, nil
+	default:
+		return nil, fmt.Errorf("lad: unknown FileEncoding %q", encoding)
+	}
+}
+
 func timeEncoder(layout string) func(time.Time, zapcore.PrimitiveArrayEncoder) {
 	return func(t time.Time, pae zapcore.PrimitiveArrayEncoder) {
 		pae.AppendString(t.Format(layout))
@@ -1,7 +1,12 @@
 package lad_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/omivix/lad"
@@ -83,3 +88,107 @@ func TestMustInitGlobal(t *testing.T) {
 	lad.L().Info("service started")
 	lad.S().Infow("request", "path", "/health", "ok", true)
 }
+
+type syncBuffer struct {
+	bytes.Buffer
+	synced bool
+}
+
+func (b *syncBuffer) Sync() error {
+	b.synced = true
+	return nil
+}
+
+func TestWithWriter(t *testing.T) {
+	var buf syncBuffer
+	l, err := lad.New(lad.WithWriter(lad.WriterConfig{
+		Level:  zap.InfoLevel,
+		Writer: &buf,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	l.Debug("dropped")
+	l.Info("kept", lad.String("k", "v"))
+	if err := lad.Sync(l); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	var row map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &row); err != nil {
+		t.Fatalf("parse json log line %q: %v", buf.String(), err)
+	}
+	if row["msg"] != "kept" || row["k"] != "v" {
+		t.Fatalf("unexpected row: %v", row)
+	}
+	if !buf.synced {
+		t.Fatal("writer Sync was not called")
+	}
+
+	if _, err := lad.New(lad.WithWriter(lad.WriterConfig{})); err == nil {
+		t.Fatal("expected error for missing writer")
+	}
+}
+
+func TestWithWriterColored(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := lad.New(lad.WithWriter(lad.WriterConfig{
+		Writer:   &buf,
+		Encoding: lad.ConsoleEncoding,
+		Colored:  true,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Warn("colored")
+	if !strings.Contains(buf.String(), "\x1b[33mWARN\x1b[0m") {
+		t.Fatalf("output %q has no colored level", buf.String())
+	}
+
+	if _, err := lad.New(lad.WithWriter(lad.WriterConfig{Writer: &buf, Colored: true})); err == nil {
+		t.Fatal("expected error for colored JSON")
+	}
+}
+
+func TestWithConsoleSplitOutput(t *testing.T) {
+	for _, split := range []zapcore.LevelEnabler{zap.WarnLevel, nil} {
+		testSplitOutput(t, split)
+	}
+}
+
+func testSplitOutput(t *testing.T, split zapcore.LevelEnabler) {
+	t.Helper()
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+
+	l := lad.MustNew(lad.WithConsole(lad.ConsoleConfig{
+		Level:       zap.DebugLevel,
+		Output:      stdout,
+		SplitOutput: true,
+		SplitLevel:  split, // nil defaults to Warn
+		ErrorOutput: stderr,
+	}))
+	l.Info("to stdout")
+	l.Warn("to stderr")
+	l.Error("also stderr")
+	_ = lad.Sync(l)
+
+	out, _ := os.ReadFile(stdout.Name())
+	errOut, _ := os.ReadFile(stderr.Name())
+	if !strings.Contains(string(out), "to stdout") || strings.Contains(string(out), "stderr") {
+		t.Fatalf("stdout=%q", out)
+	}
+	if strings.Contains(string(errOut), "to stdout") || strings.Count(string(errOut), "\n") != 2 {
+		t.Fatalf("stderr=%q", errOut)
+	}
+}
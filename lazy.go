@@ -0,0 +1,107 @@
+package lad
+
+import (
+	"slices"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Lazy constructs a field whose value is computed by fn only when an entry
+// carrying it is encoded, so expensive values cost nothing at disabled
+// levels:
+//
+//	logger.Debug("request", lad.Lazy("body", func() any { return dump(req) }))
+//
+// fn runs at most once per field, however many outputs encode the entry, and
+// its result is encoded like Any. Fields passed to Logger.With are encoded
+// when With is called; use LazyWith to defer those too.
+func Lazy(key string, fn func() any) Field {
+	l := &lazyField{key: key}
+	l.value = sync.OnceValue(func() Field { return Any(key, fn()) })
+	return Field{Key: key, Type: zapcore.InlineMarshalerType, Interface: l}
+}
+
+// LazyObject is like Lazy for values implementing zapcore.ObjectMarshaler.
+func LazyObject(key string, fn func() zapcore.ObjectMarshaler) Field {
+	l := &lazyField{key: key}
+	l.value = sync.OnceValue(func() Field { return Object(key, fn()) })
+	return Field{Key: key, Type: zapcore.InlineMarshalerType, Interface: l}
+}
+
+// lazyField is inlined, so it can add its field under its own key.
+type lazyField struct {
+	key   string
+	value func() Field
+}
+
+func (l *lazyField) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	l.value().AddTo(enc)
+	return nil
+}
+
+// LazyWith returns a child of log with the context fields returned by fn,
+// like log.With(fn()...), except that fn is called only when the child (or a
+// logger derived from it) first writes an enabled entry. Children that are
+// never used, such as loggers for rare error paths, cost nothing to build:
+//
+//	reqLog := lad.LazyWith(logger, func() []lad.Field {
+//		return []lad.Field{lad.Struct("request", req)}
+//	})
+//
+// Loggers derived from the child with With are lazy too: their fields, like
+// fn's, are encoded when they first write, so values they point to must not
+// change meanwhile.
+func LazyWith(log *Logger, fn func() []Field) *Logger {
+	return log.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &lazyWithCore{orig: core, build: func() zapcore.Core { return core.With(fn()) }}
+	}))
+}
+
+// lazyWithCore defers building its core with context fields to first use.
+// orig is the core beneath all lazy fields, which answers Enabled and Sync.
+type lazyWithCore struct {
+	orig  zapcore.Core
+	build func() zapcore.Core
+	once  sync.Once
+	core  zapcore.Core
+}
+
+func (c *lazyWithCore) init() zapcore.Core {
+	c.once.Do(func() {
+		c.core = c.build()
+		c.build = nil
+	})
+	return c.core
+}
+
+// Enabled doesn't need the fields: With never changes the level.
+func (c *lazyWithCore) Enabled(lvl zapcore.Level) bool {
+	return c.orig.Enabled(lvl)
+}
+
+// With returns a lazy child sharing c's fields, computed at most once.
+func (c *lazyWithCore) With(fields []Field) zapcore.Core {
+	if len(fields) == 0 {
+		return c
+	}
+	fields = slices.Clone(fields)
+	return &lazyWithCore{orig: c.orig, build: func() zapcore.Core { return c.init().With(fields) }}
+}
+
+func (c *lazyWithCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.orig.Enabled(ent.Level) {
+		return ce
+	}
+	return c.init().Check(ent, ce)
+}
+
+func (c *lazyWithCore) Write(ent zapcore.Entry, fields []Field) error {
+	return c.init().Write(ent, fields)
+}
+
+// Sync doesn't need the fields either; an unused child has nothing buffered.
+func (c *lazyWithCore) Sync() error {
+	return c.orig.Sync()
+}
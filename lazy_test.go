@@ -0,0 +1,81 @@
+package lad
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLazyFields(t *testing.T) {
+	var jsonOut, consoleOut bytes.Buffer
+	l, err := New(
+		WithWriter(WriterConfig{Writer: &jsonOut}),
+		WithWriter(WriterConfig{Writer: &consoleOut, Encoding: ConsoleEncoding}),
+	)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	calls := 0
+	body := func() any {
+		calls++
+		return map[string]int{"size": 3}
+	}
+	l.Debug("disabled", Lazy("body", body))
+	if calls != 0 {
+		t.Fatalf("Lazy evaluated %d times for a disabled entry", calls)
+	}
+	l.Info("enabled", Lazy("body", body), LazyObject("obj", func() zapcore.ObjectMarshaler {
+		return zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("k", "v")
+			return nil
+		})
+	}))
+	if calls != 1 {
+		t.Fatalf("Lazy evaluated %d times for two outputs, want 1", calls)
+	}
+	for _, out := range []string{jsonOut.String(), consoleOut.String()} {
+		for _, want := range []string{`"body":{"size":3}`, `"obj":{"k":"v"}`} {
+			if !strings.Contains(strings.ReplaceAll(out, `": `, `":`), want) {
+				t.Errorf("output %s does not contain %s", out, want)
+			}
+		}
+	}
+}
+
+func TestLazyWith(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	calls := 0
+	child := LazyWith(l, func() []Field {
+		calls++
+		return []Field{String("request", "r1")}
+	})
+	child.Debug("disabled")
+	grandchild := child.With(String("step", "2"))
+	if calls != 0 {
+		t.Fatalf("fields computed %d times after With, want 0", calls)
+	}
+	grandchild.Debug("disabled")
+	grandchild.Info("second")
+	child.Info("first")
+	if calls != 1 {
+		t.Fatalf("fields computed %d times, want 1", calls)
+	}
+	got := out.String()
+	if strings.Count(got, `"request":"r1"`) != 2 || !strings.Contains(got, `"step":"2"`) {
+		t.Fatalf("output %s", got)
+	}
+
+	unused := LazyWith(l, func() []Field {
+		t.Fatal("fields of an unused logger were computed")
+		return nil
+	})
+	unused.Debug("disabled")
+}
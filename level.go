@@ -0,0 +1,28 @@
+package lad
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelEnablerFunc is a convenient way to implement zapcore.LevelEnabler with
+// an anonymous function, e.g. for a per-output Enabler.
+type LevelEnablerFunc = zap.LevelEnablerFunc
+
+// LevelRange returns an enabler for levels between min and max, inclusive.
+//
+// Example: LevelRange(zap.DebugLevel, zap.InfoLevel) for an "app.log" that
+// leaves Warn and above to a separate "errors.log".
+func LevelRange(min, max zapcore.Level) zapcore.LevelEnabler {
+	return LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= min && l <= max
+	})
+}
+
+// levelEnabler prefers an explicit enabler and falls back to a minimum level.
+func levelEnabler(enabler zapcore.LevelEnabler, level zapcore.Level) zapcore.LevelEnabler {
+	if enabler != nil {
+		return enabler
+	}
+	return level
+}
@@ -0,0 +1,46 @@
+package lad
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelRangeSplitsFiles(t *testing.T) {
+	dir := t.TempDir()
+	appLog := filepath.Join(dir, "app.log")
+	errLog := filepath.Join(dir, "errors.log")
+
+	l, err := New(
+		WithFile(FileConfig{
+			Filename: appLog,
+			Enabler:  LevelRange(zapcore.DebugLevel, zapcore.InfoLevel),
+		}),
+		WithFile(FileConfig{
+			Filename: errLog,
+			Enabler: LevelEnablerFunc(func(l zapcore.Level) bool {
+				return l >= zapcore.WarnLevel
+			}),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Debug("debug line")
+	l.Info("info line")
+	l.Warn("warn line")
+	l.Error("error line")
+	_ = Sync(l)
+
+	app, _ := os.ReadFile(appLog)
+	errs, _ := os.ReadFile(errLog)
+	if got := strings.Count(string(app), "\n"); got != 2 || strings.Contains(string(app), "warn line") {
+		t.Fatalf("app.log has %d lines: %s", got, app)
+	}
+	if got := strings.Count(string(errs), "\n"); got != 2 || strings.Contains(string(errs), "info line") {
+		t.Fatalf("errors.log has %d lines: %s", got, errs)
+	}
+}
@@ -0,0 +1,259 @@
+package lad
+
+import (
+	"cmp"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Map constructs a field that encodes m as an object with its entries in key
+// order, so the same map always produces the same output. Numeric keys sort
+// numerically and come first, then booleans; other keys, including nil, are
+// rendered as text (via MarshalText or String when available) and sorted as
+// such, so unlike Any and Reflect, maps with non-string keys don't fail to
+// encode as JSON. Values are encoded like Struct fields, with common types
+// taking a fast path; a map or slice found inside itself is logged as
+// "<cycle>".
+func Map[K comparable, V any](key string, m map[K]V) Field {
+	if m == nil {
+		return nilField(key)
+	}
+	return Object(key, mapMarshaler[K, V](m))
+}
+
+type mapMarshaler[K comparable, V any] map[K]V
+
+func (m mapMarshaler[K, V]) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	entries := make([]mapEntry, 0, len(m))
+	for k, v := range m {
+		entries = append(entries, mapEntry{key: reflect.ValueOf(k), val: v})
+	}
+	return addMapEntries(enc, entries, nil)
+}
+
+// anyMap is mapMarshaler[string, any] within a value, tracking its path.
+type anyMap struct {
+	m    map[string]any
+	path valuePath
+}
+
+func (m anyMap) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	entries := make([]mapEntry, 0, len(m.m))
+	for k, v := range m.m {
+		entries = append(entries, mapEntry{key: reflect.ValueOf(k), val: v})
+	}
+	return addMapEntries(enc, entries, m.path)
+}
+
+type mapEntry struct {
+	key  reflect.Value
+	name string
+	val  any
+	rval reflect.Value // used instead of val when valid
+}
+
+// addMapEntries names and sorts entries, then adds them to enc. path holds
+// the values the map is part of.
+func addMapEntries(enc zapcore.ObjectEncoder, entries []mapEntry, path valuePath) error {
+	for i := range entries {
+		entries[i].name = mapKeyName(entries[i].key)
+	}
+	slices.SortFunc(entries, func(a, b mapEntry) int {
+		if c := compareMapKeys(a.key, b.key); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.name, b.name)
+	})
+	for _, e := range entries {
+		var err error
+		if e.rval.IsValid() {
+			err = addValue(enc, e.name, e.rval, path)
+		} else {
+			err = addAny(enc, e.name, e.val, path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// mapKeyName renders a map key the way encoding/json would, falling back to
+// String and fmt for keys json rejects.
+func mapKeyName(k reflect.Value) string {
+	if k.Kind() == reflect.Interface {
+		if k.IsNil() {
+			return "<nil>"
+		}
+		k = k.Elem()
+	}
+	if !k.IsValid() {
+		// A nil key of an interface type K, as in map[any]V or map[error]V.
+		return "<nil>"
+	}
+	if k.CanInterface() && k.Type().Implements(textMarshalerType) {
+		if k.Kind() != reflect.Pointer || !k.IsNil() {
+			if b, err := k.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+				return string(b)
+			}
+		}
+	}
+	switch k.Kind() {
+	case reflect.String:
+		return k.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10)
+	}
+	if k.CanInterface() {
+		return fmt.Sprint(k.Interface())
+	}
+	return k.String()
+}
+
+// compareMapKeys orders numeric keys first, by value, then booleans, false
+// first. Keys comparing equal, including all others, are ordered by name.
+// Ranking kinds keeps the order total when interface keys mix them.
+func compareMapKeys(a, b reflect.Value) int {
+	a, b = mapKeyElem(a), mapKeyElem(b)
+	ra, rb := mapKeyRank(a), mapKeyRank(b)
+	if ra != rb {
+		return cmp.Compare(ra, rb)
+	}
+	switch ra {
+	case 0:
+		switch {
+		case a.CanInt() && b.CanInt():
+			return cmp.Compare(a.Int(), b.Int())
+		case a.CanUint() && b.CanUint():
+			return cmp.Compare(a.Uint(), b.Uint())
+		}
+		return cmp.Compare(mapKeyFloat(a), mapKeyFloat(b))
+	case 1:
+		return cmp.Compare(boolRank(a.Bool()), boolRank(b.Bool()))
+	}
+	return 0
+}
+
+func mapKeyElem(k reflect.Value) reflect.Value {
+	if k.Kind() == reflect.Interface && !k.IsNil() {
+		return k.Elem()
+	}
+	return k
+}
+
+// mapKeyRank groups keys: numbers, booleans, then the rest.
+func mapKeyRank(k reflect.Value) int {
+	switch {
+	case k.CanInt(), k.CanUint(), k.CanFloat():
+		return 0
+	case k.Kind() == reflect.Bool:
+		return 1
+	}
+	return 2
+}
+
+func mapKeyFloat(k reflect.Value) float64 {
+	switch {
+	case k.CanInt():
+		return float64(k.Int())
+	case k.CanUint():
+		return float64(k.Uint())
+	}
+	return k.Float()
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// addAny adds v under key, handling the types most often found in
+// map[string]any without reflection. path holds the values v is part of.
+func addAny(enc zapcore.ObjectEncoder, key string, v any, path valuePath) error {
+	switch v := v.(type) {
+	case nil:
+		return enc.AddReflected(key, nil)
+	case string:
+		enc.AddString(key, v)
+	case bool:
+		enc.AddBool(key, v)
+	case int:
+		enc.AddInt(key, v)
+	case int64:
+		enc.AddInt64(key, v)
+	case int32:
+		enc.AddInt32(key, v)
+	case uint:
+		enc.AddUint(key, v)
+	case uint64:
+		enc.AddUint64(key, v)
+	case float64:
+		enc.AddFloat64(key, v)
+	case float32:
+		enc.AddFloat32(key, v)
+	case time.Time:
+		enc.AddTime(key, v)
+	case time.Duration:
+		enc.AddDuration(key, v)
+	case []byte:
+		enc.AddBinary(key, v)
+	case map[string]any, []any:
+		// The JSON-like values that can contain themselves.
+		rv := reflect.ValueOf(v)
+		path, visited, ok := path.enter(rv)
+		if !ok {
+			enc.AddString(key, cycleValue)
+			return nil
+		}
+		defer delete(path, visited)
+		if m, ok := v.(map[string]any); ok {
+			return enc.AddObject(key, anyMap{m, path})
+		}
+		return enc.AddArray(key, valueArray{rv, path})
+	case zapcore.ObjectMarshaler:
+		return enc.AddObject(key, v)
+	case zapcore.ArrayMarshaler:
+		return enc.AddArray(key, v)
+	case json.RawMessage:
+		RawJSON(key, v).AddTo(enc)
+	default:
+		return addValue(enc, key, reflect.ValueOf(v), path)
+	}
+	return nil
+}
+
+// RawJSON constructs a field that embeds data, a pre-encoded JSON value, in
+// JSON output as is (only insignificant whitespace is removed), rather than
+// as an escaped string. Console output shows data as a string, and other
+// outputs, such as syslog, as text.
+//
+// data is validated up front; invalid JSON is logged as a string instead, so
+// it can't corrupt the entry. data must not be modified after the call.
+func RawJSON(key string, data []byte) Field {
+	if !json.Valid(data) {
+		return ByteString(key, data)
+	}
+	return Field{Key: key, Type: zapcore.ReflectType, Interface: rawJSON(data)}
+}
+
+// rawJSON is already valid JSON, so encoding/json embeds it verbatim.
+type rawJSON []byte
+
+func (r rawJSON) MarshalJSON() ([]byte, error) { return r, nil }
+
+func (r rawJSON) String() string { return string(r) }
+
+func (r rawJSON) consoleField(key string) Field { return ByteString(key, r) }
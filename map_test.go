@@ -0,0 +1,117 @@
+package lad
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMapField(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Info("maps",
+		Map("attrs", map[string]any{
+			"b":      2,
+			"a":      "x",
+			"nested": map[string]any{"z": true, "y": []any{1, "two"}},
+			"nil":    nil,
+		}),
+		Map("byCode", map[int]string{10: "ten", 2: "two", -1: "minus"}),
+		Map("byStatus", map[status]int{"ok": 1}),
+	)
+
+	got := out.String()
+	for _, want := range []string{
+		`"attrs":{"a":"x","b":2,"nested":{"y":[1,"two"],"z":true},"nil":null}`,
+		`"byCode":{"-1":"minus","2":"two","10":"ten"}`,
+		`"byStatus":{"ok":1}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %s does not contain %s", got, want)
+		}
+	}
+}
+
+func TestMapFieldMixedKeys(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	var nilErr error
+	m := map[any]int{10: 1, 9: 2, "5": 3, "a": 4, 100: 5, 2.5: 6, uint8(3): 7, true: 8, false: 9, nil: 10}
+	want := `"m":{"2.5":6,"3":7,"9":2,"10":1,"100":5,"false":9,"true":8,"5":3,"<nil>":10,"a":4}`
+	for range 50 {
+		out.Reset()
+		l.Info("keys", Map("m", m), Map("errs", map[error]int{nilErr: 1}))
+		got := out.String()
+		if !strings.Contains(got, want) {
+			t.Fatalf("output %s does not contain %s", got, want)
+		}
+		if !strings.Contains(got, `"errs":{"<nil>":1}`) {
+			t.Fatalf("output %s has no nil error key", got)
+		}
+	}
+}
+
+func TestMapFieldCycle(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	m := map[string]any{"name": "m"}
+	list := []any{1, nil}
+	list[1] = list
+	m["self"], m["list"] = m, list
+	l.Info("cycle", Map("m", m))
+
+	want := `"m":{"list":[1,"<cycle>"],"name":"m","self":{"list":[1,"<cycle>"],"name":"m","self":"<cycle>"}}`
+	if got := out.String(); !strings.Contains(got, want) {
+		t.Errorf("output %s does not contain %s", got, want)
+	}
+}
+
+func TestRawJSONField(t *testing.T) {
+	var jsonOut, consoleOut bytes.Buffer
+	l, err := New(
+		WithWriter(WriterConfig{Writer: &jsonOut}),
+		WithWriter(WriterConfig{Writer: &consoleOut, Encoding: ConsoleEncoding}),
+	)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.With(RawJSON("ctx", []byte(`{"trace": [1, 2]}`))).Info("raw",
+		RawJSON("payload", []byte(`{"id":7,"tags":["a"]}`)),
+		RawJSON("broken", []byte(`{"id":`)),
+	)
+
+	var entry map[string]any
+	if err := json.Unmarshal(jsonOut.Bytes(), &entry); err != nil {
+		t.Fatalf("JSON output %q: %v", jsonOut.String(), err)
+	}
+	got := jsonOut.String()
+	for _, want := range []string{
+		`"ctx":{"trace":[1,2]}`,
+		`"payload":{"id":7,"tags":["a"]}`,
+		`"broken":"{\"id\":"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("JSON output %s does not contain %s", got, want)
+		}
+	}
+
+	got = consoleOut.String()
+	for _, want := range []string{
+		`"ctx": "{\"trace\": [1, 2]}"`,
+		`"payload": "{\"id\":7,\"tags\":[\"a\"]}"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("console output %s does not contain %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package lad
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// NetworkConfig controls streaming output to a socket.
+type NetworkConfig struct {
+	Level   zapcore.Level
+	Enabler zapcore.LevelEnabler // Overrides Level when set (see LevelRange).
+
+	Network string      // "tcp", "udp" or "unix" (and their variants). Required.
+	Address string      // Required.
+	TLS     *tls.Config // Optional; enables TLS for stream networks.
+
+	Encoding   FileEncoding  // Defaults to JSONEncoding (newline-delimited).
+	TimeFormat string        // Defaults to DefaultTimeFormat when empty.
+	Encoder    EncoderConfig // Key names and value formats; zero value keeps defaults.
+
+	BufferBytes  int           // In-memory buffer while disconnected. Defaults to 4 MiB.
+	DialTimeout  time.Duration // Defaults to 5s; also used as the write deadline.
+	MinBackoff   time.Duration // First reconnect delay. Defaults to 100ms.
+	MaxBackoff   time.Duration // Reconnect delay cap. Defaults to 30s.
+	FlushTimeout time.Duration // How long Sync waits for the buffer to drain. Defaults to 5s.
+
+	// SpoolDir, when set, buffers entries in segment files in this directory
+	// instead of memory: they are removed only after delivery and replayed
+	// by the next process, so shipping is at-least-once. SpoolBytes caps the
+	// disk usage (default 256 MiB) by evicting the oldest entries, and
+	// BufferBytes then caps how much is read back into memory at a time.
+	// Each output needs its own directory.
+	SpoolDir   string
+	SpoolBytes int
+
+	Stats *SinkStats // Optional; receives reconnect and drop counters.
+}
+
+// WithNetwork adds a core streaming encoded entries to a socket address.
+//
+// Entries are buffered in memory and written by a background goroutine, so a
+// slow or unreachable peer never blocks the logger. While disconnected, it
+// reconnects with exponential backoff; entries that do not fit into the
+// buffer are dropped and counted in Stats. UDP sends one entry per datagram.
+func WithNetwork(nc NetworkConfig) Option {
+	return func(c *config) error {
+		c.coreBuilders = append(c.coreBuilders, func(cfg *config) (zapcore.Core, error) {
+			if nc.Network == "" || nc.Address == "" {
+				return nil, errors.New("lad: NetworkConfig.Network and Address are required")
+			}
+			datagram := false
+			switch nc.Network {
+			case "tcp", "tcp4", "tcp6", "unix":
+			case "udp", "udp4", "udp6", "unixgram":
+				datagram = true
+			default:
+				return nil, fmt.Errorf("lad: unsupported network %q", nc.Network)
+			}
+			if datagram && nc.TLS != nil {
+				return nil, fmt.Errorf("lad: TLS is not supported over %q", nc.Network)
+			}
+
+			encCfg, err := cfg.encoderConfig(nc.Encoder, nc.TimeFormat, false)
+			if err != nil {
+				return nil, err
+			}
+			enc, err := newEncoder(nc.Encoding, encCfg)
+			if err != nil {
+				return nil, err
+			}
+
+			ns := &netSender{
+				network:  nc.Network,
+				address:  nc.Address,
+				tls:      nc.TLS,
+				timeout:  nc.DialTimeout,
+				datagram: datagram,
+				stats:    nc.Stats,
+			}
+			if ns.timeout <= 0 {
+				ns.timeout = 5 * time.Second
+			}
+			if ns.stats == nil {
+				ns.stats = &SinkStats{}
+			}
+			queue, err := newRecordQueue(nc.SpoolDir, nc.SpoolBytes, nc.BufferBytes)
+			if err != nil {
+				return nil, err
+			}
+			s := cfg.ship(&shipper{
+				deliver:      ns.deliver,
+				closeSender:  ns.close,
+				queue:        queue,
+				flushTimeout: nc.FlushTimeout,
+				minBackoff:   nc.MinBackoff,
+				maxBackoff:   nc.MaxBackoff,
+				stats:        ns.stats,
+			})
+
+			return zapcore.NewCore(enc, s, levelEnabler(nc.Enabler, nc.Level)), nil
+		})
+		return nil
+	}
+}
+
+// netSender owns the connection of a network output. It is only used from
+// the shipper goroutine.
+type netSender struct {
+	network  string
+	address  string
+	tls      *tls.Config
+	timeout  time.Duration
+	datagram bool
+	stats    *SinkStats
+
+	conn      net.Conn
+	peerGone  chan struct{} // closed when the peer closes a stream connection
+	connected bool          // a connection succeeded before
+}
+
+func (ns *netSender) deliver(batch [][]byte) error {
+	if ns.conn != nil && ns.peerClosed() {
+		_ = ns.conn.Close()
+		ns.conn = nil
+	}
+	if ns.conn == nil {
+		if err := ns.dial(); err != nil {
+			return err
+		}
+	}
+	_ = ns.conn.SetWriteDeadline(time.Now().Add(ns.timeout))
+
+	var err error
+	if ns.datagram {
+		for _, rec := range batch {
+			if _, err = ns.conn.Write(rec); err != nil {
+				break
+			}
+		}
+	} else {
+		// WriteTo consumes the slices it is given, so hand it a copy of the
+		// headers: the batch stays queued for a retry when the write fails.
+		bufs := append(net.Buffers(nil), batch...)
+		_, err = bufs.WriteTo(ns.conn)
+	}
+	if err != nil {
+		_ = ns.conn.Close()
+		ns.conn = nil
+		return fmt.Errorf("lad: write to %s %s: %w", ns.network, ns.address, err)
+	}
+	return nil
+}
+
+// close releases the connection after the shipper has stopped.
+func (ns *netSender) close() error {
+	if ns.conn == nil {
+		return nil
+	}
+	err := ns.conn.Close()
+	ns.conn = nil
+	return err
+}
+
+func (ns *netSender) dial() error {
+	dialer := &net.Dialer{Timeout: ns.timeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if ns.tls != nil {
+		conn, err = tls.DialWithDialer(dialer, ns.network, ns.address, ns.tls)
+	} else {
+		conn, err = dialer.Dial(ns.network, ns.address)
+	}
+	if err != nil {
+		return fmt.Errorf("lad: dial %s %s: %w", ns.network, ns.address, err)
+	}
+	if ns.connected {
+		ns.stats.reconnects.Add(1)
+	}
+	ns.connected = true
+	ns.conn = conn
+
+	// Writes to a connection the peer has closed may still succeed locally,
+	// losing the data. Reading until EOF notices the close in time to redial.
+	ns.peerGone = make(chan struct{})
+	if !ns.datagram {
+		go func(conn net.Conn, gone chan struct{}) {
+			_, _ = io.Copy(io.Discard, conn)
+			close(gone)
+		}(conn, ns.peerGone)
+	}
+	return nil
+}
+
+func (ns *netSender) peerClosed() bool {
+	select {
+	case <-ns.peerGone:
+		return true
+	default:
+		return false
+	}
+}
+
+func orDefaultInt(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,161 @@
+package lad
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWithNetworkReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	lines := make(chan map[string]any, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			sc := bufio.NewScanner(conn)
+			if sc.Scan() {
+				var row map[string]any
+				_ = json.Unmarshal(sc.Bytes(), &row)
+				lines <- row
+			}
+			conn.Close() // drop every connection after one line
+		}
+	}()
+
+	stats := &SinkStats{}
+	l, err := New(WithNetwork(NetworkConfig{
+		Network:    "tcp",
+		Address:    ln.Addr().String(),
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+		Stats:      stats,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	// The peer may close between our check and the write, in which case TCP
+	// loses the entry; keep logging until each message made it through.
+	for _, msg := range []string{"one", "two", "three"} {
+		deadline := time.After(5 * time.Second)
+		for got := false; !got; {
+			l.Info(msg)
+			select {
+			case row := <-lines:
+				got = row["msg"] == msg
+			case <-time.After(100 * time.Millisecond):
+			case <-deadline:
+				t.Fatalf("message %q not received", msg)
+			}
+		}
+	}
+	if stats.Reconnects() == 0 {
+		t.Fatal("expected reconnects to be counted")
+	}
+}
+
+func TestWithNetworkDropsWhenBufferFull(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close() // nothing listens: every dial fails
+
+	stats := &SinkStats{}
+	l, err := New(WithNetwork(NetworkConfig{
+		Network:      "tcp",
+		Address:      addr,
+		BufferBytes:  256,
+		FlushTimeout: 50 * time.Millisecond,
+		Stats:        stats,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		l.Info("entry that will not fit", Int("i", i))
+	}
+	if err := l.Sync(); err == nil {
+		t.Fatal("expected Sync to report queued entries")
+	}
+	if stats.DroppedEntries() == 0 || stats.DroppedBytes() == 0 {
+		t.Fatalf("dropped entries=%d bytes=%d, want > 0", stats.DroppedEntries(), stats.DroppedBytes())
+	}
+}
+
+func TestCloseStopsNetwork(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	received := make(chan string, 16)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		sc := bufio.NewScanner(conn)
+		for sc.Scan() {
+			var row map[string]any
+			_ = json.Unmarshal(sc.Bytes(), &row)
+			received <- row["msg"].(string)
+		}
+		close(received) // the logger closed the connection
+	}()
+
+	dir := t.TempDir()
+	stats := &SinkStats{}
+	cfg := NetworkConfig{Network: "tcp", Address: ln.Addr().String(), SpoolDir: dir, Stats: stats}
+	l, err := New(WithNetwork(cfg))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	if _, err := New(WithNetwork(cfg)); err == nil {
+		t.Fatal("expected a second logger on the same spool to fail")
+	}
+
+	l.With(String("k", "v")).Info("before close")
+	if err := Close(l.With(String("k", "v"))); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	l.Info("after close")
+
+	var got []string
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case msg, ok := <-received:
+			if !ok {
+				done = true
+			} else {
+				got = append(got, msg)
+			}
+		case <-timeout:
+			t.Fatal("connection not closed")
+		}
+	}
+	if len(got) != 1 || got[0] != "before close" {
+		t.Fatalf("received %q, want only the entry logged before Close", got)
+	}
+	if stats.DroppedEntries() != 1 {
+		t.Fatalf("dropped=%d, want 1", stats.DroppedEntries())
+	}
+
+	l, err = New(WithNetwork(cfg))
+	if err != nil {
+		t.Fatalf("reopen spool after close: %v", err)
+	}
+	_ = Close(l)
+}
@@ -0,0 +1,581 @@
+package lad
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// OTLPProtocol selects the OTLP/HTTP payload encoding.
+type OTLPProtocol string
+
+const (
+	// OTLPProtobuf sends binary protobuf payloads (default).
+	OTLPProtobuf OTLPProtocol = "http/protobuf"
+	// OTLPJSON sends OTLP/JSON payloads.
+	OTLPJSON OTLPProtocol = "http/json"
+)
+
+// OTLPConfig controls export of entries as OpenTelemetry log records.
+type OTLPConfig struct {
+	Level   zapcore.Level
+	Enabler zapcore.LevelEnabler // Overrides Level when set (see LevelRange).
+
+	Endpoint    string       // Logs endpoint, e.g. "http://collector:4318/v1/logs". Required.
+	Protocol    OTLPProtocol // Defaults to OTLPProtobuf.
+	Header      http.Header  // Extra request headers (auth, ...).
+	Client      *http.Client // Defaults to a client with a 10s timeout.
+	DisableGzip bool         // Send uncompressed bodies.
+
+	// TraceIDKey and SpanIDKey name the fields holding hex trace and span
+	// IDs; they become the record's trace context instead of attributes.
+	TraceIDKey string // Defaults to "trace_id".
+	SpanIDKey  string // Defaults to "span_id".
+
+	BatchSize     int           // Max records per request. Defaults to 512.
+	FlushInterval time.Duration // Max time a record waits for its batch. Defaults to 1s.
+	BufferBytes   int           // In-memory buffer. Defaults to 4 MiB.
+	MaxRetries    int           // Attempts after the first failure. Defaults to 5.
+	MinBackoff    time.Duration // First retry delay. Defaults to 100ms.
+	MaxBackoff    time.Duration // Retry delay cap. Defaults to 30s.
+	FlushTimeout  time.Duration // How long Sync waits for the buffer to drain. Defaults to 5s.
+
+	SpoolDir   string // Optional; durable on-disk buffer (see NetworkConfig.SpoolDir).
+	SpoolBytes int    // Disk cap of SpoolDir. Defaults to 256 MiB.
+
+	Stats *SinkStats // Optional; receives delivery and drop counters.
+}
+
+// WithResource sets resource attributes describing the process, such as
+// "service.name" and "service.version". They are sent once per OTLP request.
+// When "service.name" is missing, "unknown_service:<executable>" is used.
+func WithResource(attrs map[string]string) Option {
+	return func(c *config) error {
+		if c.resource == nil {
+			c.resource = make(map[string]string, len(attrs))
+		}
+		for k, v := range attrs {
+			if strings.TrimSpace(k) == "" {
+				return errors.New("lad: resource attribute key cannot be empty")
+			}
+			c.resource[k] = v
+		}
+		return nil
+	}
+}
+
+// WithOTLP adds a core exporting entries as OpenTelemetry log records over
+// OTLP/HTTP. Levels map to severity numbers, fields to attributes, the caller
+// to code.* attributes and the logger name to the instrumentation scope.
+func WithOTLP(oc OTLPConfig) Option {
+	return func(c *config) error {
+		c.coreBuilders = append(c.coreBuilders, func(cfg *config) (zapcore.Core, error) {
+			if strings.TrimSpace(oc.Endpoint) == "" {
+				return nil, errors.New("lad: OTLPConfig.Endpoint is required")
+			}
+			protocol := oc.Protocol
+			switch protocol {
+			case "":
+				protocol = OTLPProtobuf
+			case OTLPProtobuf, OTLPJSON:
+			default:
+				return nil, fmt.Errorf("lad: unknown OTLPProtocol %q", protocol)
+			}
+
+			resource := make(map[string]string, len(cfg.resource)+1)
+			for k, v := range cfg.resource {
+				resource[k] = v
+			}
+			if resource["service.name"] == "" {
+				resource["service.name"] = "unknown_service:" + filepath.Base(os.Args[0])
+			}
+
+			sender := &otlpSender{
+				endpoint: oc.Endpoint,
+				protocol: protocol,
+				header:   oc.Header,
+				client:   oc.Client,
+				gzip:     !oc.DisableGzip,
+				resource: sortedKeyValues(resource),
+			}
+			var closeSender func() error
+			if sender.client == nil {
+				sender.client = &http.Client{Timeout: 10 * time.Second}
+				closeSender = closeIdleConns(sender.client)
+			}
+			queue, err := newRecordQueue(oc.SpoolDir, oc.SpoolBytes, oc.BufferBytes)
+			if err != nil {
+				return nil, err
+			}
+			s := cfg.ship(&shipper{
+				deliver:       sender.deliver,
+				closeSender:   closeSender,
+				queue:         queue,
+				maxBatch:      orDefaultInt(oc.BatchSize, 512),
+				flushInterval: orDefaultDuration(oc.FlushInterval, time.Second),
+				flushTimeout:  oc.FlushTimeout,
+				minBackoff:    oc.MinBackoff,
+				maxBackoff:    oc.MaxBackoff,
+				maxRetries:    orDefaultInt(oc.MaxRetries, 5),
+				stats:         oc.Stats,
+			})
+
+			core := &otlpCore{
+				LevelEnabler: levelEnabler(oc.Enabler, oc.Level),
+				protocol:     protocol,
+				traceIDKey:   orDefault(oc.TraceIDKey, "trace_id"),
+				spanIDKey:    orDefault(oc.SpanIDKey, "span_id"),
+				out:          s,
+			}
+			return core, nil
+		})
+		return nil
+	}
+}
+
+type otlpCore struct {
+	zapcore.LevelEnabler
+	protocol   OTLPProtocol
+	traceIDKey string
+	spanIDKey  string
+	fields     []Field
+	out        *shipper
+}
+
+func (oc *otlpCore) With(fields []Field) zapcore.Core {
+	clone := *oc
+	clone.fields = append(oc.fields[:len(oc.fields):len(oc.fields)], fields...)
+	return &clone
+}
+
+func (oc *otlpCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if oc.Enabled(ent.Level) {
+		return ce.AddCore(ent, oc)
+	}
+	return ce
+}
+
+func (oc *otlpCore) Write(ent zapcore.Entry, fields []Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range oc.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	rec := otlpRecord{
+		time:         uint64(ent.Time.UnixNano()),
+		severity:     otlpSeverity(ent.Level),
+		severityText: ent.Level.CapitalString(),
+		body:         ent.Message,
+	}
+	if id, ok := hexID(enc.Fields[oc.traceIDKey], 16); ok {
+		rec.traceID = id
+		delete(enc.Fields, oc.traceIDKey)
+	}
+	if id, ok := hexID(enc.Fields[oc.spanIDKey], 8); ok {
+		rec.spanID = id
+		delete(enc.Fields, oc.spanIDKey)
+	}
+	rec.attrs = otlpAttributes(enc.Fields)
+	if ent.Caller.Defined {
+		rec.attrs = append(rec.attrs,
+			otlpKeyValue{"code.file.path", ent.Caller.File},
+			otlpKeyValue{"code.line.number", int64(ent.Caller.Line)},
+		)
+		if ent.Caller.Function != "" {
+			rec.attrs = append(rec.attrs, otlpKeyValue{"code.function.name", ent.Caller.Function})
+		}
+	}
+	if ent.Stack != "" {
+		rec.attrs = append(rec.attrs, otlpKeyValue{"code.stacktrace", ent.Stack})
+	}
+
+	var payload []byte
+	if oc.protocol == OTLPJSON {
+		var err error
+		if payload, err = json.Marshal(rec.json()); err != nil {
+			return err
+		}
+	} else {
+		payload = rec.proto()
+	}
+
+	// Queued records carry their scope (the logger name) in front.
+	queued := binary.AppendUvarint(nil, uint64(len(ent.LoggerName)))
+	queued = append(queued, ent.LoggerName...)
+	queued = append(queued, payload...)
+	if _, err := oc.out.Write(queued); err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		return oc.out.Sync()
+	}
+	return nil
+}
+
+func (oc *otlpCore) Sync() error { return oc.out.Sync() }
+
+// otlpSeverity maps zap levels to OpenTelemetry severity numbers.
+func otlpSeverity(l zapcore.Level) int {
+	switch l {
+	case zapcore.DebugLevel:
+		return 5 // DEBUG
+	case zapcore.InfoLevel:
+		return 9 // INFO
+	case zapcore.WarnLevel:
+		return 13 // WARN
+	case zapcore.ErrorLevel:
+		return 17 // ERROR
+	case zapcore.DPanicLevel:
+		return 18 // ERROR2
+	case zapcore.PanicLevel:
+		return 19 // ERROR3
+	case zapcore.FatalLevel:
+		return 21 // FATAL
+	default:
+		if l < zapcore.DebugLevel {
+			return 1 // TRACE
+		}
+		return 21
+	}
+}
+
+func hexID(v any, size int) ([]byte, bool) {
+	s, ok := v.(string)
+	if !ok || len(s) != 2*size {
+		return nil, false
+	}
+	id, err := hex.DecodeString(s)
+	if err != nil || bytes.Count(id, []byte{0}) == size {
+		return nil, false
+	}
+	return id, true
+}
+
+// otlpKeyValue is an attribute. value is a string, bool, int64, float64,
+// []byte, []any of such values, or []otlpKeyValue.
+type otlpKeyValue struct {
+	key   string
+	value any
+}
+
+type otlpRecord struct {
+	time         uint64
+	severity     int
+	severityText string
+	body         string
+	attrs        []otlpKeyValue
+	traceID      []byte
+	spanID       []byte
+}
+
+func sortedKeyValues(m map[string]string) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(m))
+	for k, v := range m {
+		kvs = append(kvs, otlpKeyValue{k, v})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].key < kvs[j].key })
+	return kvs
+}
+
+func otlpAttributes(fields map[string]any) []otlpKeyValue {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, otlpKeyValue{k, otlpValue(fields[k])})
+	}
+	return kvs
+}
+
+// otlpValue normalizes values produced by zapcore.MapObjectEncoder.
+func otlpValue(v any) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string, bool, int64, float64, []byte:
+		return v
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case uint:
+		return uintValue(uint64(v))
+	case uint64:
+		return uintValue(v)
+	case uint32:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uintptr:
+		return uintValue(uint64(v))
+	case float32:
+		return float64(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case time.Duration:
+		return v.String()
+	case map[string]any:
+		return otlpAttributes(v)
+	case []any:
+		vals := make([]any, len(v))
+		for i, e := range v {
+			vals[i] = otlpValue(e)
+		}
+		return vals
+	case plainValuer:
+		return otlpValue(v.plainValue())
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func uintValue(v uint64) any {
+	if v > math.MaxInt64 {
+		return strconv.FormatUint(v, 10)
+	}
+	return int64(v)
+}
+
+// json returns the OTLP/JSON form of the record.
+func (r *otlpRecord) json() map[string]any {
+	m := map[string]any{
+		"timeUnixNano":         strconv.FormatUint(r.time, 10),
+		"observedTimeUnixNano": strconv.FormatUint(r.time, 10),
+		"severityNumber":       r.severity,
+		"severityText":         r.severityText,
+		"body":                 map[string]any{"stringValue": r.body},
+		"attributes":           jsonKeyValues(r.attrs),
+	}
+	if r.traceID != nil {
+		m["traceId"] = hex.EncodeToString(r.traceID)
+	}
+	if r.spanID != nil {
+		m["spanId"] = hex.EncodeToString(r.spanID)
+	}
+	return m
+}
+
+func jsonKeyValues(kvs []otlpKeyValue) []any {
+	out := make([]any, len(kvs))
+	for i, kv := range kvs {
+		out[i] = map[string]any{"key": kv.key, "value": jsonAnyValue(kv.value)}
+	}
+	return out
+}
+
+func jsonAnyValue(v any) map[string]any {
+	switch v := v.(type) {
+	case string:
+		return map[string]any{"stringValue": v}
+	case bool:
+		return map[string]any{"boolValue": v}
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]any{"doubleValue": v}
+	case []byte:
+		return map[string]any{"bytesValue": v}
+	case []any:
+		vals := make([]any, len(v))
+		for i, e := range v {
+			vals[i] = jsonAnyValue(e)
+		}
+		return map[string]any{"arrayValue": map[string]any{"values": vals}}
+	case []otlpKeyValue:
+		return map[string]any{"kvlistValue": map[string]any{"values": jsonKeyValues(v)}}
+	default:
+		return map[string]any{}
+	}
+}
+
+// proto returns the protobuf encoding of the LogRecord message.
+func (r *otlpRecord) proto() []byte {
+	var b protoBuf
+	b.fixed64(1, r.time)
+	b.varint(2, uint64(r.severity))
+	b.string(3, r.severityText)
+	b.message(5, func(b *protoBuf) { protoAnyValue(b, r.body) })
+	for _, kv := range r.attrs {
+		b.message(6, func(b *protoBuf) { protoKeyValue(b, kv) })
+	}
+	if r.traceID != nil {
+		b.bytes(9, r.traceID)
+	}
+	if r.spanID != nil {
+		b.bytes(10, r.spanID)
+	}
+	b.fixed64(11, r.time)
+	return b
+}
+
+func protoKeyValue(b *protoBuf, kv otlpKeyValue) {
+	b.string(1, kv.key)
+	b.message(2, func(b *protoBuf) { protoAnyValue(b, kv.value) })
+}
+
+func protoAnyValue(b *protoBuf, v any) {
+	switch v := v.(type) {
+	case string:
+		b.string(1, v)
+	case bool:
+		var bit uint64
+		if v {
+			bit = 1
+		}
+		b.varint(2, bit)
+	case int64:
+		b.varint(3, uint64(v))
+	case float64:
+		b.tag(4, 1)
+		*b = binary.LittleEndian.AppendUint64(*b, math.Float64bits(v))
+	case []any:
+		b.message(5, func(b *protoBuf) {
+			for _, e := range v {
+				b.message(1, func(b *protoBuf) { protoAnyValue(b, e) })
+			}
+		})
+	case []otlpKeyValue:
+		b.message(6, func(b *protoBuf) {
+			for _, kv := range v {
+				b.message(1, func(b *protoBuf) { protoKeyValue(b, kv) })
+			}
+		})
+	case []byte:
+		b.bytes(7, v)
+	}
+}
+
+// protoBuf is a minimal protobuf wire-format writer.
+type protoBuf []byte
+
+func (b *protoBuf) tag(field, wireType int) {
+	*b = binary.AppendUvarint(*b, uint64(field)<<3|uint64(wireType))
+}
+
+func (b *protoBuf) varint(field int, v uint64) {
+	b.tag(field, 0)
+	*b = binary.AppendUvarint(*b, v)
+}
+
+func (b *protoBuf) fixed64(field int, v uint64) {
+	b.tag(field, 1)
+	*b = binary.LittleEndian.AppendUint64(*b, v)
+}
+
+func (b *protoBuf) bytes(field int, v []byte) {
+	b.tag(field, 2)
+	*b = binary.AppendUvarint(*b, uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *protoBuf) string(field int, v string) {
+	b.tag(field, 2)
+	*b = binary.AppendUvarint(*b, uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *protoBuf) message(field int, fn func(*protoBuf)) {
+	var inner protoBuf
+	fn(&inner)
+	b.bytes(field, inner)
+}
+
+// otlpSender posts export requests; it is only used from the shipper goroutine.
+type otlpSender struct {
+	endpoint string
+	protocol OTLPProtocol
+	header   http.Header
+	client   *http.Client
+	gzip     bool
+	resource []otlpKeyValue
+}
+
+func (ot *otlpSender) deliver(batch [][]byte) error {
+	// Group records by scope, keeping first-seen order.
+	var scopes []string
+	byScope := map[string][][]byte{}
+	for _, queued := range batch {
+		n, size := binary.Uvarint(queued)
+		if size <= 0 || uint64(len(queued)-size) < n {
+			return &permanentError{err: errors.New("lad: corrupt queued OTLP record")}
+		}
+		scope := string(queued[size : size+int(n)])
+		if _, ok := byScope[scope]; !ok {
+			scopes = append(scopes, scope)
+		}
+		byScope[scope] = append(byScope[scope], queued[size+int(n):])
+	}
+
+	if ot.protocol == OTLPJSON {
+		scopeLogs := make([]any, 0, len(scopes))
+		for _, scope := range scopes {
+			records := make([]json.RawMessage, 0, len(byScope[scope]))
+			for _, rec := range byScope[scope] {
+				records = append(records, rec)
+			}
+			scopeLogs = append(scopeLogs, map[string]any{
+				"scope":      map[string]any{"name": scope},
+				"logRecords": records,
+			})
+		}
+		body, err := json.Marshal(map[string]any{
+			"resourceLogs": []any{map[string]any{
+				"resource":  map[string]any{"attributes": jsonKeyValues(ot.resource)},
+				"scopeLogs": scopeLogs,
+			}},
+		})
+		if err != nil {
+			return &permanentError{err: err}
+		}
+		_, err = postBody(ot.client, ot.endpoint, ot.header, "application/json", body, ot.gzip)
+		return err
+	}
+
+	var req protoBuf
+	req.message(1, func(b *protoBuf) { // ResourceLogs
+		b.message(1, func(b *protoBuf) { // Resource
+			for _, kv := range ot.resource {
+				b.message(1, func(b *protoBuf) { protoKeyValue(b, kv) })
+			}
+		})
+		for _, scope := range scopes {
+			b.message(2, func(b *protoBuf) { // ScopeLogs
+				b.message(1, func(b *protoBuf) { // InstrumentationScope
+					if scope != "" {
+						b.string(1, scope)
+					}
+				})
+				for _, rec := range byScope[scope] {
+					b.bytes(2, rec)
+				}
+			})
+		}
+	})
+	_, err := postBody(ot.client, ot.endpoint, ot.header, "application/x-protobuf", req, ot.gzip)
+	return err
+}
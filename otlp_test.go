@@ -0,0 +1,152 @@
+package lad
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithOTLPJSON(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type=%q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	l, err := New(
+		WithOTLP(OTLPConfig{
+			Endpoint:      srv.URL,
+			Protocol:      OTLPJSON,
+			DisableGzip:   true,
+			FlushInterval: time.Hour,
+		}),
+		WithResource(map[string]string{"service.name": "billing", "service.version": "1.2.3"}),
+		WithCaller(),
+	)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Named("api").Warn("slow",
+		String("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"),
+		String("span_id", "00f067aa0ba902b7"),
+		Int("ms", 1200),
+	)
+	if err := l.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	var req struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []map[string]any `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				Scope      map[string]any `json:"scope"`
+				LogRecords []struct {
+					SeverityNumber int              `json:"severityNumber"`
+					Body           map[string]any   `json:"body"`
+					TraceID        string           `json:"traceId"`
+					SpanID         string           `json:"spanId"`
+					Attributes     []map[string]any `json:"attributes"`
+				} `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+	if err := json.Unmarshal(<-bodies, &req); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	rl := req.ResourceLogs[0]
+	if len(rl.Resource.Attributes) != 2 || rl.Resource.Attributes[0]["key"] != "service.name" {
+		t.Fatalf("resource=%v", rl.Resource.Attributes)
+	}
+	if rl.ScopeLogs[0].Scope["name"] != "api" {
+		t.Fatalf("scope=%v", rl.ScopeLogs[0].Scope)
+	}
+	rec := rl.ScopeLogs[0].LogRecords[0]
+	if rec.SeverityNumber != 13 || rec.Body["stringValue"] != "slow" {
+		t.Fatalf("record=%+v", rec)
+	}
+	if rec.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || rec.SpanID != "00f067aa0ba902b7" {
+		t.Fatalf("trace=%q span=%q", rec.TraceID, rec.SpanID)
+	}
+	keys := map[string]any{}
+	for _, attr := range rec.Attributes {
+		keys[attr["key"].(string)] = attr["value"]
+	}
+	if v, _ := keys["ms"].(map[string]any); v["intValue"] != "1200" {
+		t.Fatalf("ms=%v", keys["ms"])
+	}
+	if _, ok := keys["code.file.path"]; !ok {
+		t.Fatalf("missing code.file.path in %v", keys)
+	}
+	if _, ok := keys["trace_id"]; ok {
+		t.Fatal("trace_id should not be an attribute")
+	}
+}
+
+func TestOTLPRecordProto(t *testing.T) {
+	rec := otlpRecord{
+		time:         42,
+		severity:     otlpSeverity(zapcore.ErrorLevel),
+		severityText: "ERROR",
+		body:         "boom",
+		attrs:        []otlpKeyValue{{"n", int64(0)}, {"ok", false}},
+		spanID:       []byte{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	fields := readProto(t, rec.proto())
+	if got := binary.LittleEndian.Uint64(fields[1][0]); got != 42 {
+		t.Fatalf("time=%d", got)
+	}
+	if sev, _ := binary.Uvarint(fields[2][0]); sev != 17 {
+		t.Fatalf("severity=%d", sev)
+	}
+	if body := readProto(t, fields[5][0]); string(body[1][0]) != "boom" {
+		t.Fatalf("body=%q", body[1][0])
+	}
+	if len(fields[6]) != 2 || len(fields[10][0]) != 8 {
+		t.Fatalf("attrs=%d span=%x", len(fields[6]), fields[10])
+	}
+	// Zero values in a oneof must still be encoded.
+	zero := readProto(t, readProto(t, fields[6][0])[2][0])
+	if _, ok := zero[3]; !ok {
+		t.Fatal("int_value 0 was not encoded")
+	}
+}
+
+// readProto splits a protobuf message into raw field values by number.
+// Varints are returned in their encoded form.
+func readProto(t *testing.T, b []byte) map[int][][]byte {
+	t.Helper()
+
+	out := map[int][][]byte{}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		b = b[n:]
+		field, wire := int(key>>3), key&7
+		var val []byte
+		switch wire {
+		case 0:
+			_, n = binary.Uvarint(b)
+			val, b = b[:n], b[n:]
+		case 1:
+			val, b = b[:8], b[8:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			val, b = b[n:n+int(size)], b[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", wire)
+		}
+		out[field] = append(out[field], val)
+	}
+	return out
+}
@@ -0,0 +1,260 @@
+package lad
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RingBuffer keeps the most recent entries in memory. Fill it with
+// WithRingBuffer, read it with Snapshot and stream it live with Handler; one
+// buffer may be shared by several loggers. All methods are safe for
+// concurrent use.
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []RingEntry
+	next    int // index of the slot written next
+	full    bool
+	subs    map[*ringSubscriber]struct{}
+}
+
+// RingEntry is an entry retained by a RingBuffer.
+type RingEntry struct {
+	zapcore.Entry
+	Fields []Field // context fields followed by the entry's own fields
+	Line   []byte  // the encoded entry, including the line ending
+}
+
+// NewRingBuffer returns a buffer retaining the last size entries.
+func NewRingBuffer(size int) *RingBuffer {
+	return &RingBuffer{entries: make([]RingEntry, max(size, 1))}
+}
+
+// RingFilter selects entries of a RingBuffer. The zero value matches all.
+type RingFilter struct {
+	Level  zapcore.LevelEnabler // e.g. zapcore.WarnLevel for Warn and above; nil matches all.
+	Logger string               // The named logger and its children (see MatchLogger).
+	Since  time.Time            // Entries at or after Since; zero means unbounded.
+	Until  time.Time            // Entries before Until; zero means unbounded.
+	Match  RouteMatcher         // e.g. MatchField("request_id", id).
+	Limit  int                  // Keep only the newest Limit matches; 0 keeps all.
+}
+
+func (f RingFilter) matches(e *RingEntry) bool {
+	switch {
+	case f.Level != nil && !f.Level.Enabled(e.Level):
+		return false
+	case f.Logger != "" && e.LoggerName != f.Logger && !strings.HasPrefix(e.LoggerName, f.Logger+"."):
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	case f.Match != nil && !f.Match(e.Entry, e.Fields):
+		return false
+	}
+	return true
+}
+
+// Snapshot returns the retained entries accepted by f, oldest first.
+func (rb *RingBuffer) Snapshot(f RingFilter) []RingEntry {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	var out []RingEntry
+	rb.each(func(e *RingEntry) {
+		if f.matches(e) {
+			out = append(out, *e)
+		}
+	})
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out
+}
+
+// Bytes returns the encoded lines of the entries accepted by f, oldest
+// first, e.g. to attach the recent log tail to an error report.
+func (rb *RingBuffer) Bytes(f RingFilter) []byte {
+	var buf bytes.Buffer
+	for _, e := range rb.Snapshot(f) {
+		buf.Write(e.Line)
+	}
+	return buf.Bytes()
+}
+
+// Len returns the number of retained entries.
+func (rb *RingBuffer) Len() int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if rb.full {
+		return len(rb.entries)
+	}
+	return rb.next
+}
+
+// Reset discards all retained entries.
+func (rb *RingBuffer) Reset() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	clear(rb.entries)
+	rb.next, rb.full = 0, false
+}
+
+func (rb *RingBuffer) add(e RingEntry) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.entries[rb.next] = e
+	rb.next++
+	if rb.next == len(rb.entries) {
+		rb.next, rb.full = 0, true
+	}
+	for sub := range rb.subs {
+		if !sub.filter.matches(&e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			// Never block the logger: drop subscribers that fall behind.
+			delete(rb.subs, sub)
+			close(sub.dropped)
+		}
+	}
+}
+
+// ringSubscriber receives new entries accepted by filter until it is
+// unsubscribed or dropped for falling behind.
+type ringSubscriber struct {
+	filter  RingFilter
+	ch      chan RingEntry
+	dropped chan struct{}
+}
+
+// subscribe registers a subscriber and returns it together with the
+// retained entries accepted by f (see Snapshot), so that none are missed
+// or seen twice.
+func (rb *RingBuffer) subscribe(f RingFilter, queue int) (*ringSubscriber, []RingEntry) {
+	sub := &ringSubscriber{
+		filter:  RingFilter{Level: f.Level, Logger: f.Logger, Match: f.Match},
+		ch:      make(chan RingEntry, queue),
+		dropped: make(chan struct{}),
+	}
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	var backlog []RingEntry
+	if f.Limit > 0 {
+		rb.each(func(e *RingEntry) {
+			if f.matches(e) {
+				backlog = append(backlog, *e)
+			}
+		})
+		backlog = backlog[max(len(backlog)-f.Limit, 0):]
+	}
+	if rb.subs == nil {
+		rb.subs = make(map[*ringSubscriber]struct{})
+	}
+	rb.subs[sub] = struct{}{}
+	return sub, backlog
+}
+
+func (rb *RingBuffer) unsubscribe(sub *ringSubscriber) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	delete(rb.subs, sub)
+}
+
+// each calls fn for the retained entries, oldest first. rb.mu must be held.
+func (rb *RingBuffer) each(fn func(*RingEntry)) {
+	if rb.full {
+		for i := rb.next; i < len(rb.entries); i++ {
+			fn(&rb.entries[i])
+		}
+	}
+	for i := 0; i < rb.next; i++ {
+		fn(&rb.entries[i])
+	}
+}
+
+// RingBufferConfig controls output to a RingBuffer.
+type RingBufferConfig struct {
+	Level   zapcore.Level
+	Enabler zapcore.LevelEnabler // Overrides Level when set (see LevelRange).
+	Buffer  *RingBuffer          // Required; see NewRingBuffer.
+
+	Encoding   FileEncoding  // Encoding of RingEntry.Line. Defaults to JSONEncoding.
+	TimeFormat string        // Defaults to DefaultTimeFormat when empty.
+	Encoder    EncoderConfig // Key names and value formats; zero value keeps defaults.
+}
+
+// WithRingBuffer adds a core retaining the most recent entries in a
+// RingBuffer, e.g. to expose the log tail on a debug endpoint without
+// reading files.
+func WithRingBuffer(rc RingBufferConfig) Option {
+	return func(c *config) error {
+		c.coreBuilders = append(c.coreBuilders, func(cfg *config) (zapcore.Core, error) {
+			if rc.Buffer == nil {
+				return nil, errors.New("lad: RingBufferConfig.Buffer is required")
+			}
+			encCfg, err := cfg.encoderConfig(rc.Encoder, rc.TimeFormat, false)
+			if err != nil {
+				return nil, err
+			}
+			enc, err := newEncoder(rc.Encoding, encCfg)
+			if err != nil {
+				return nil, err
+			}
+			core := &ringCore{
+				LevelEnabler: levelEnabler(rc.Enabler, rc.Level),
+				enc:          enc,
+				rb:           rc.Buffer,
+			}
+			return core, nil
+		})
+		return nil
+	}
+}
+
+type ringCore struct {
+	zapcore.LevelEnabler
+	enc    zapcore.Encoder
+	fields []Field
+	rb     *RingBuffer
+}
+
+func (rc *ringCore) With(fields []Field) zapcore.Core {
+	clone := *rc
+	clone.enc = rc.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	clone.fields = append(rc.fields[:len(rc.fields):len(rc.fields)], fields...)
+	return &clone
+}
+
+func (rc *ringCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if rc.Enabled(ent.Level) {
+		return ce.AddCore(ent, rc)
+	}
+	return ce
+}
+
+func (rc *ringCore) Write(ent zapcore.Entry, fields []Field) error {
+	buf, err := rc.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	line := bytes.Clone(buf.Bytes())
+	buf.Free()
+
+	all := make([]Field, 0, len(rc.fields)+len(fields))
+	all = append(append(all, rc.fields...), fields...)
+	rc.rb.add(RingEntry{Entry: ent, Fields: all, Line: line})
+	return nil
+}
+
+func (rc *ringCore) Sync() error { return nil }
@@ -0,0 +1,64 @@
+package lad
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithRingBuffer(t *testing.T) {
+	rb := NewRingBuffer(4)
+	l, err := New(WithRingBuffer(RingBufferConfig{Level: zapcore.DebugLevel, Buffer: rb}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	start := time.Now()
+	l.Debug("dropped by the ring")
+	l.Named("api").Info("one", String("request_id", "r1"))
+	l.Named("api.v2").Warn("two", String("request_id", "r2"))
+	l.With(String("request_id", "r1")).Error("three")
+	l.Named("db").Info("four")
+
+	if rb.Len() != 4 {
+		t.Fatalf("len=%d, want 4", rb.Len())
+	}
+	messages := func(entries []RingEntry) string {
+		var msgs []string
+		for _, e := range entries {
+			msgs = append(msgs, e.Message)
+		}
+		return strings.Join(msgs, ",")
+	}
+
+	tests := []struct {
+		name   string
+		filter RingFilter
+		want   string
+	}{
+		{"all", RingFilter{}, "one,two,three,four"},
+		{"level", RingFilter{Level: zapcore.WarnLevel}, "two,three"},
+		{"logger", RingFilter{Logger: "api"}, "one,two"},
+		{"field", RingFilter{Match: MatchField("request_id", "r1")}, "one,three"},
+		{"since", RingFilter{Since: start}, "one,two,three,four"},
+		{"until", RingFilter{Until: start}, ""},
+		{"limit", RingFilter{Limit: 2}, "three,four"},
+	}
+	for _, tt := range tests {
+		if got := messages(rb.Snapshot(tt.filter)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	tail := string(rb.Bytes(RingFilter{Level: zapcore.ErrorLevel}))
+	if !strings.Contains(tail, `"msg":"three"`) || !strings.Contains(tail, `"request_id":"r1"`) || !strings.HasSuffix(tail, "\n") {
+		t.Fatalf("tail=%q", tail)
+	}
+
+	rb.Reset()
+	if rb.Len() != 0 || len(rb.Snapshot(RingFilter{})) != 0 {
+		t.Fatal("reset kept entries")
+	}
+}
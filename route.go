@@ -0,0 +1,265 @@
+package lad
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RouteMatcher decides whether an entry belongs to a route. fields holds the
+// logger's context fields (added with With) followed by the entry's own fields.
+type RouteMatcher func(ent zapcore.Entry, fields []Field) bool
+
+// MatchLogger matches entries from the named logger and its children,
+// e.g. "payments" matches "payments" and "payments.stripe".
+func MatchLogger(name string) RouteMatcher {
+	return func(ent zapcore.Entry, _ []Field) bool {
+		return ent.LoggerName == name || strings.HasPrefix(ent.LoggerName, name+".")
+	}
+}
+
+// MatchField matches entries carrying a field named key whose value equals
+// value. Values are compared by their fmt.Sprint representation, so
+// MatchField("audit", true) matches Bool("audit", true) and Any("audit", true).
+func MatchField(key string, value any) RouteMatcher {
+	want := fmt.Sprint(value)
+	return func(_ zapcore.Entry, fields []Field) bool {
+		for _, f := range fields {
+			if f.Key != key {
+				continue
+			}
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+			if got, ok := enc.Fields[key]; ok && fmt.Sprint(got) == want {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchAll matches entries accepted by every matcher.
+func MatchAll(matchers ...RouteMatcher) RouteMatcher {
+	return func(ent zapcore.Entry, fields []Field) bool {
+		for _, m := range matchers {
+			if !m(ent, fields) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchAny matches entries accepted by at least one matcher.
+func MatchAny(matchers ...RouteMatcher) RouteMatcher {
+	return func(ent zapcore.Entry, fields []Field) bool {
+		for _, m := range matchers {
+			if m(ent, fields) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type route struct {
+	match    RouteMatcher // nil for the default route
+	builders []func(*config) (zapcore.Core, error)
+}
+
+// WithRoute sends entries accepted by match to the outputs configured by opts
+// (WithConsole, WithFile, WithWriter, ...). An entry is written to every route
+// it matches; route outputs still apply their own levels.
+//
+// Route outputs share the logger-wide settings such as WithCallerPathFrom and
+// WithTimeZone, so opts must only contain output options.
+func WithRoute(match RouteMatcher, opts ...Option) Option {
+	return func(c *config) error {
+		if match == nil {
+			return errors.New("lad: route matcher cannot be nil")
+		}
+		return c.addRoute(match, opts)
+	}
+}
+
+// WithDefaultRoute sends entries that matched no WithRoute to the outputs
+// configured by opts, e.g. an "app.log" for everything that is neither audit
+// nor payments traffic.
+func WithDefaultRoute(opts ...Option) Option {
+	return func(c *config) error {
+		return c.addRoute(nil, opts)
+	}
+}
+
+func (c *config) addRoute(match RouteMatcher, opts []Option) error {
+	builders, err := outputBuilders("route", opts)
+	if err != nil {
+		return err
+	}
+
+	if match == nil {
+		for _, r := range c.routes {
+			if r.match == nil {
+				return errors.New("lad: default route already configured")
+			}
+		}
+	}
+	if len(c.routes) == 0 {
+		c.coreBuilders = append(c.coreBuilders, buildRouter)
+	}
+	c.routes = append(c.routes, route{match: match, builders: builders})
+	return nil
+}
+
+// outputBuilders applies opts to an empty config and returns the core
+// builders they add. what names the caller in errors.
+func outputBuilders(what string, opts []Option) ([]func(*config) (zapcore.Core, error), error) {
+	sub := &config{}
+	for _, opt := range opts {
+		if err := opt(sub); err != nil {
+			return nil, err
+		}
+	}
+	if len(sub.coreBuilders) == 0 {
+		return nil, fmt.Errorf("lad: %s needs at least one output option", what)
+	}
+	if len(sub.zapOpts) > 0 || sub.callerEncode != nil || sub.location != nil || len(sub.routes) > 0 || sub.resource != nil {
+		return nil, fmt.Errorf("lad: %s options must only configure outputs", what)
+	}
+	return sub.coreBuilders, nil
+}
+
+// buildTee builds the cores of builders into a single core.
+func buildTee(cfg *config, builders []func(*config) (zapcore.Core, error)) (zapcore.Core, error) {
+	cores := make([]zapcore.Core, 0, len(builders))
+	for _, build := range builders {
+		core, err := build(cfg)
+		if err != nil {
+			return nil, err
+		}
+		cores = append(cores, core)
+	}
+	return zapcore.NewTee(cores...), nil
+}
+
+func buildRouter(cfg *config) (zapcore.Core, error) {
+	rc := &routerCore{}
+	for _, r := range cfg.routes {
+		core, err := buildTee(cfg, r.builders)
+		if err != nil {
+			return nil, err
+		}
+		if r.match == nil {
+			rc.fallback = core
+			continue
+		}
+		rc.routes = append(rc.routes, matchedCore{match: r.match, core: core})
+	}
+	return rc, nil
+}
+
+type matchedCore struct {
+	match RouteMatcher
+	core  zapcore.Core
+}
+
+// routerCore dispatches each entry to the routes whose matcher accepts it, or
+// to the fallback when none does.
+type routerCore struct {
+	routes   []matchedCore
+	fallback zapcore.Core
+	fields   []Field
+}
+
+func (rc *routerCore) Enabled(l zapcore.Level) bool {
+	for _, r := range rc.routes {
+		if r.core.Enabled(l) {
+			return true
+		}
+	}
+	return rc.fallback != nil && rc.fallback.Enabled(l)
+}
+
+func (rc *routerCore) With(fields []Field) zapcore.Core {
+	clone := &routerCore{
+		routes: make([]matchedCore, len(rc.routes)),
+		fields: append(rc.fields[:len(rc.fields):len(rc.fields)], fields...),
+	}
+	for i, r := range rc.routes {
+		clone.routes[i] = matchedCore{match: r.match, core: r.core.With(fields)}
+	}
+	if rc.fallback != nil {
+		clone.fallback = rc.fallback.With(fields)
+	}
+	return clone
+}
+
+func (rc *routerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if rc.Enabled(ent.Level) {
+		return ce.AddCore(ent, rc)
+	}
+	return ce
+}
+
+func (rc *routerCore) Write(ent zapcore.Entry, fields []Field) error {
+	all := fields
+	if len(rc.fields) > 0 {
+		all = append(rc.fields[:len(rc.fields):len(rc.fields)], fields...)
+	}
+
+	var errs []error
+	matched := false
+	for _, r := range rc.routes {
+		if !r.match(ent, all) {
+			continue
+		}
+		matched = true
+		if err := writeChecked(r.core, ent, fields); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !matched && rc.fallback != nil {
+		if err := writeChecked(rc.fallback, ent, fields); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (rc *routerCore) Sync() error {
+	var errs []error
+	for _, r := range rc.routes {
+		errs = append(errs, r.core.Sync())
+	}
+	if rc.fallback != nil {
+		errs = append(errs, rc.fallback.Sync())
+	}
+	return errors.Join(errs...)
+}
+
+// writeChecked writes through core.Check so that each wrapped core applies its
+// own level, and reports write failures back to the caller.
+func writeChecked(core zapcore.Core, ent zapcore.Entry, fields []Field) error {
+	ce := core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+	sink := &errorSink{}
+	ce.ErrorOutput = sink
+	ce.Write(fields...)
+	return sink.err
+}
+
+// errorSink turns the error report of a CheckedEntry back into an error.
+type errorSink struct {
+	err error
+}
+
+func (s *errorSink) Write(p []byte) (int, error) {
+	s.err = errors.Join(s.err, errors.New(strings.TrimSpace(string(p))))
+	return len(p), nil
+}
+
+func (s *errorSink) Sync() error { return nil }
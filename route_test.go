@@ -0,0 +1,51 @@
+package lad
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWithRoute(t *testing.T) {
+	var audit, payments, app bytes.Buffer
+	l, err := New(
+		WithRoute(MatchField("audit", true), WithWriter(WriterConfig{Writer: &audit})),
+		WithRoute(MatchLogger("payments"), WithWriter(WriterConfig{Writer: &payments})),
+		WithDefaultRoute(WithWriter(WriterConfig{Writer: &app})),
+	)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	l.Info("login", Bool("audit", true))
+	l.With(Bool("audit", true)).Info("logout")
+	l.Named("payments").Named("stripe").Info("charge")
+	l.Info("plain")
+	l.Info("not audit", Bool("audit", false))
+
+	assertLines(t, "audit", audit.String(), "login", "logout")
+	assertLines(t, "payments", payments.String(), "charge")
+	assertLines(t, "app", app.String(), "plain", "not audit")
+}
+
+func TestWithRouteRejectsNonOutputOptions(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := New(WithRoute(MatchLogger("x"), WithWriter(WriterConfig{Writer: &buf}), WithCaller()))
+	if err == nil {
+		t.Fatal("expected error for non-output route option")
+	}
+}
+
+func assertLines(t *testing.T, name, got string, msgs ...string) {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != len(msgs) {
+		t.Fatalf("%s: got %d lines, want %d:\n%s", name, len(lines), len(msgs), got)
+	}
+	for i, msg := range msgs {
+		if !strings.Contains(lines[i], `"msg":"`+msg+`"`) {
+			t.Fatalf("%s: line %d=%q, want msg %q", name, i, lines[i], msg)
+		}
+	}
+}
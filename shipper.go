@@ -0,0 +1,441 @@
+package lad
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SinkStats counts delivery events of a remote output. Pass a pointer in the
+// output's config and read the counters at any time; all methods are safe for
+// concurrent use.
+type SinkStats struct {
+	sentEntries    atomic.Int64
+	sentBytes      atomic.Int64
+	droppedEntries atomic.Int64
+	droppedBytes   atomic.Int64
+	failures       atomic.Int64
+	reconnects     atomic.Int64
+}
+
+// SentEntries returns the number of entries delivered successfully.
+func (s *SinkStats) SentEntries() int64 { return s.sentEntries.Load() }
+
+// SentBytes returns the number of encoded bytes delivered successfully.
+func (s *SinkStats) SentBytes() int64 { return s.sentBytes.Load() }
+
+// DroppedEntries returns the number of entries discarded because the buffer
+// was full or delivery was given up.
+func (s *SinkStats) DroppedEntries() int64 { return s.droppedEntries.Load() }
+
+// DroppedBytes returns the encoded size of the dropped entries.
+func (s *SinkStats) DroppedBytes() int64 { return s.droppedBytes.Load() }
+
+// Failures returns the number of failed delivery attempts.
+func (s *SinkStats) Failures() int64 { return s.failures.Load() }
+
+// Reconnects returns how often a connection was re-established after the
+// first successful one.
+func (s *SinkStats) Reconnects() int64 { return s.reconnects.Load() }
+
+func (s *SinkStats) drop(records [][]byte) {
+	s.droppedEntries.Add(int64(len(records)))
+	s.droppedBytes.Add(int64(recordsSize(records)))
+}
+
+const (
+	defaultBufferBytes  = 4 << 20
+	defaultMinBackoff   = 100 * time.Millisecond
+	defaultMaxBackoff   = 30 * time.Second
+	defaultFlushTimeout = 5 * time.Second
+)
+
+// shipper delivers encoded records from a bounded queue in a background
+// goroutine, retrying failed batches with exponential backoff. Records are
+// only removed from the queue once delivered (or given up), so a queue
+// backed by durable storage gets at-least-once delivery. Close stops the
+// goroutine and releases the queue and connection.
+type shipper struct {
+	deliver     func(batch [][]byte) error
+	closeSender func() error // releases the connection; optional
+
+	maxBatch      int           // records per delivery; 0 means unlimited
+	maxBatchBytes int           // bytes per delivery; 0 means unlimited
+	flushInterval time.Duration // how long to wait for a batch to fill up
+	flushTimeout  time.Duration // how long Sync waits for the queue to drain
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	maxRetries    int                             // 0 retries forever
+	giveUp        func(batch [][]byte, err error) // called after maxRetries
+	stats         *SinkStats
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    recordQueue
+	flushing int
+	lastErr  error
+	closed   bool
+
+	stop      chan struct{} // closed by Close
+	stopped   chan struct{} // closed when run returns
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// recordQueue is the storage behind a shipper. peek returns records from the
+// head without removing them; ack removes the first n records.
+type recordQueue interface {
+	push(rec []byte) bool
+	peek(maxRecords, maxBytes int) [][]byte
+	ack(n int)
+	len() int
+	size() int
+}
+
+func (s *shipper) start() *shipper {
+	if s.stats == nil {
+		s.stats = &SinkStats{}
+	}
+	if s.queue == nil {
+		s.queue = &memQueue{max: defaultBufferBytes}
+	}
+	if dq, ok := s.queue.(*diskQueue); ok {
+		dq.stats = s.stats
+	}
+	if s.minBackoff <= 0 {
+		s.minBackoff = defaultMinBackoff
+	}
+	if s.maxBackoff < s.minBackoff {
+		s.maxBackoff = max(defaultMaxBackoff, s.minBackoff)
+	}
+	if s.flushTimeout <= 0 {
+		s.flushTimeout = defaultFlushTimeout
+	}
+	s.cond = sync.NewCond(&s.mu)
+	s.stop = make(chan struct{})
+	s.stopped = make(chan struct{})
+	go s.run()
+	return s
+}
+
+// ship starts s and registers it to be closed by Close.
+func (c *config) ship(s *shipper) *shipper {
+	c.closers = append(c.closers, s.start())
+	return s
+}
+
+// Close delivers the queued records, waiting up to the flush timeout, then
+// stops the shipper and closes its queue and connection. Records written
+// afterwards are dropped; those still in a disk queue are kept for the next
+// process.
+func (s *shipper) Close() error {
+	s.closeOnce.Do(func() {
+		err := s.Sync()
+
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.stop)
+		s.cond.Broadcast()
+		<-s.stopped
+
+		errs := []error{err}
+		s.mu.Lock()
+		if q, ok := s.queue.(*memQueue); ok {
+			s.stats.drop(q.records)
+			q.ack(q.len())
+		}
+		if c, ok := s.queue.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+		s.mu.Unlock()
+		if s.closeSender != nil {
+			errs = append(errs, s.closeSender())
+		}
+		s.closeErr = errors.Join(errs...)
+	})
+	return s.closeErr
+}
+
+// Write queues a copy of p. Entries that do not fit into the buffer, or
+// arrive after Close, are dropped and counted rather than blocking the
+// logger.
+func (s *shipper) Write(p []byte) (int, error) {
+	rec := append([]byte(nil), p...)
+	s.mu.Lock()
+	ok := !s.closed && s.queue.push(rec)
+	s.mu.Unlock()
+	if !ok {
+		s.stats.drop([][]byte{rec})
+		return len(p), nil
+	}
+	s.cond.Broadcast()
+	return len(p), nil
+}
+
+// Sync waits until every queued record is delivered or the flush timeout
+// expires.
+func (s *shipper) Sync() error {
+	deadline := time.Now().Add(s.flushTimeout)
+	timer := time.AfterFunc(s.flushTimeout, s.cond.Broadcast)
+	defer timer.Stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushing++
+	defer func() { s.flushing-- }()
+	s.cond.Broadcast()
+	for s.queue.len() > 0 && !s.closed {
+		if !time.Now().Before(deadline) {
+			err := fmt.Errorf("lad: %d entries still queued after %v", s.queue.len(), s.flushTimeout)
+			if s.lastErr != nil {
+				err = fmt.Errorf("%w: %w", err, s.lastErr)
+			}
+			return err
+		}
+		s.cond.Wait()
+	}
+	return nil
+}
+
+func (s *shipper) run() {
+	defer close(s.stopped)
+	for {
+		batch := s.next()
+		if batch == nil {
+			return
+		}
+		n := len(batch)
+		for attempt := 1; ; attempt++ {
+			err := s.deliver(batch)
+			if err == nil {
+				s.stats.sentEntries.Add(int64(len(batch)))
+				s.stats.sentBytes.Add(int64(recordsSize(batch)))
+				s.done(n, nil)
+				break
+			}
+			var partial *partialError
+			if errors.As(err, &partial) {
+				failed := len(partial.retry) + len(partial.rejected)
+				s.stats.sentEntries.Add(int64(len(batch) - failed))
+				s.stats.sentBytes.Add(int64(recordsSize(batch) - recordsSize(partial.retry) - recordsSize(partial.rejected)))
+				if len(partial.rejected) > 0 {
+					s.stats.drop(partial.rejected)
+					if s.giveUp != nil {
+						s.giveUp(partial.rejected, err)
+					}
+				}
+				batch = partial.retry
+				if len(batch) == 0 {
+					s.done(n, nil)
+					break
+				}
+			}
+			s.stats.failures.Add(1)
+			var perm *permanentError
+			if errors.As(err, &perm) || (s.maxRetries > 0 && attempt > s.maxRetries) {
+				s.stats.drop(batch)
+				if s.giveUp != nil {
+					s.giveUp(batch, err)
+				}
+				s.done(n, err)
+				break
+			}
+			s.setErr(err)
+			if !s.sleep(s.backoff(attempt)) {
+				return
+			}
+		}
+	}
+}
+
+// sleep waits for d and reports false if the shipper was closed meanwhile.
+func (s *shipper) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-s.stop:
+		return false
+	}
+}
+
+// next blocks until records are queued, then waits up to flushInterval for
+// a full batch unless Sync is waiting. It returns nil once the shipper is
+// closed.
+func (s *shipper) next() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.queue.len() == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if s.flushInterval > 0 {
+		deadline := time.Now().Add(s.flushInterval)
+		timer := time.AfterFunc(s.flushInterval, s.cond.Broadcast)
+		for s.flushing == 0 && !s.closed && !s.batchFull() && time.Now().Before(deadline) {
+			s.cond.Wait()
+		}
+		timer.Stop()
+	}
+	if s.closed {
+		return nil
+	}
+	return s.queue.peek(s.maxBatch, s.maxBatchBytes)
+}
+
+func (s *shipper) batchFull() bool {
+	return (s.maxBatch > 0 && s.queue.len() >= s.maxBatch) ||
+		(s.maxBatchBytes > 0 && s.queue.size() >= s.maxBatchBytes)
+}
+
+func (s *shipper) done(n int, err error) {
+	s.mu.Lock()
+	s.queue.ack(n)
+	s.lastErr = err
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+func (s *shipper) setErr(err error) {
+	s.mu.Lock()
+	s.lastErr = err
+	s.mu.Unlock()
+}
+
+// backoff doubles from minBackoff up to maxBackoff with up to 20% jitter.
+func (s *shipper) backoff(attempt int) time.Duration {
+	d := s.minBackoff
+	for i := 1; i < attempt && d < s.maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, s.maxBackoff)
+	return d - time.Duration(rand.Int64N(int64(d)/5+1))
+}
+
+// memQueue is an in-memory FIFO of records bounded by total size.
+type memQueue struct {
+	max     int
+	records [][]byte
+	bytes   int
+}
+
+func (q *memQueue) push(rec []byte) bool {
+	if q.bytes+len(rec) > q.max {
+		return false
+	}
+	q.records = append(q.records, rec)
+	q.bytes += len(rec)
+	return true
+}
+
+func (q *memQueue) peek(maxRecords, maxBytes int) [][]byte {
+	n, size := 0, 0
+	for n < len(q.records) {
+		if maxRecords > 0 && n == maxRecords {
+			break
+		}
+		if maxBytes > 0 && n > 0 && size+len(q.records[n]) > maxBytes {
+			break
+		}
+		size += len(q.records[n])
+		n++
+	}
+	return q.records[:n:n]
+}
+
+func (q *memQueue) ack(n int) {
+	q.bytes -= recordsSize(q.records[:n])
+	clear(q.records[:n])
+	q.records = q.records[n:]
+}
+
+func (q *memQueue) len() int  { return len(q.records) }
+func (q *memQueue) size() int { return q.bytes }
+
+func recordsSize(records [][]byte) int {
+	n := 0
+	for _, r := range records {
+		n += len(r)
+	}
+	return n
+}
+
+// permanentError marks a delivery failure that retrying cannot fix, such as
+// a rejected request; the shipper gives the batch up immediately.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// partialError reports that only some records of a batch failed. The
+// shipper retries retry, gives rejected up and counts the rest as delivered.
+type partialError struct {
+	err      error
+	retry    [][]byte
+	rejected [][]byte
+}
+
+func (e *partialError) Error() string { return e.err.Error() }
+func (e *partialError) Unwrap() error { return e.err }
+
+// recordCore encodes entries like zap's ioCore, but hands each one to a
+// shipper through record, which may wrap the encoded line with per-entry
+// metadata (labels, index names, ...) needed by the remote end.
+type recordCore struct {
+	zapcore.LevelEnabler
+	enc    zapcore.Encoder
+	fields []Field // context fields, for record
+	record func(ent zapcore.Entry, fields []Field, line []byte) ([]byte, error)
+	out    *shipper
+}
+
+func (rc *recordCore) With(fields []Field) zapcore.Core {
+	clone := *rc
+	clone.enc = rc.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	clone.fields = append(rc.fields[:len(rc.fields):len(rc.fields)], fields...)
+	return &clone
+}
+
+func (rc *recordCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if rc.Enabled(ent.Level) {
+		return ce.AddCore(ent, rc)
+	}
+	return ce
+}
+
+func (rc *recordCore) Write(ent zapcore.Entry, fields []Field) error {
+	buf, err := rc.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	all := fields
+	if len(rc.fields) > 0 {
+		all = append(rc.fields[:len(rc.fields):len(rc.fields)], fields...)
+	}
+	rec, err := rc.record(ent, all, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if _, err := rc.out.Write(rec); err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		// Like zap's own cores, flush before a panic or exit.
+		return rc.out.Sync()
+	}
+	return nil
+}
+
+func (rc *recordCore) Sync() error { return rc.out.Sync() }
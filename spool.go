@@ -0,0 +1,483 @@
+package lad
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSpoolBytes   = 256 << 20
+	maxSpoolSegment     = 8 << 20
+	spoolRecordHeader   = 8 // length (4) + CRC-32 (4)
+	spoolSegmentSuffix  = ".seg"
+	spoolCursorFilename = "cursor"
+	spoolLockFilename   = "lock"
+)
+
+// newRecordQueue returns the queue of a remote output: a diskQueue in dir
+// when set, reading at most bufferBytes into memory at a time, or an
+// in-memory queue of bufferBytes otherwise.
+func newRecordQueue(dir string, spoolBytes, bufferBytes int) (recordQueue, error) {
+	bufferBytes = orDefaultInt(bufferBytes, defaultBufferBytes)
+	if dir == "" {
+		return &memQueue{max: bufferBytes}, nil
+	}
+	q, err := openDiskQueue(dir, int64(orDefaultInt(spoolBytes, defaultSpoolBytes)))
+	if err != nil {
+		return nil, err
+	}
+	q.peekBytes = bufferBytes
+	return q, nil
+}
+
+// diskQueue is a write-ahead spool: records are appended to segment files
+// and stay on disk until acknowledged, so entries queued when the process
+// exits are replayed by the next one. A cursor file remembers how far the
+// head segment was acknowledged. When the spool exceeds its size, whole
+// segments are evicted oldest first, except those being delivered.
+//
+// Writes are not fsynced: the spool survives process crashes, not
+// necessarily machine crashes.
+type diskQueue struct {
+	dir          string
+	max          int64 // bytes on disk
+	segmentBytes int64
+	stats        *SinkStats // counts evicted records; set by the shipper
+	peekBytes    int        // payload bytes peek holds in memory
+	lock         io.Closer
+
+	segs  []*spoolSegment // oldest first; the last one is written to
+	w     *os.File
+	total int64 // file bytes of all segments
+
+	count int // unacknowledged records
+	bytes int // unacknowledged payload bytes
+
+	ackOff int64 // acknowledged offset in segs[0]
+
+	readSeq uint64 // position of the next record to peek
+	readOff int64
+	r       *os.File
+	rSeq    uint64
+
+	pending    [][]byte   // peeked but unacknowledged records
+	pendingEnd []spoolPos // position after each pending record
+}
+
+type spoolSegment struct {
+	seq     uint64
+	size    int64 // file bytes
+	records int   // unacknowledged records
+	bytes   int   // unacknowledged payload bytes
+}
+
+type spoolPos struct {
+	seq uint64
+	off int64
+}
+
+func openDiskQueue(dir string, maxBytes int64) (*diskQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("lad: create spool: %w", err)
+	}
+	lock, err := lockSpool(filepath.Join(dir, spoolLockFilename))
+	if errors.Is(err, errSpoolLocked) {
+		return nil, fmt.Errorf("lad: spool %s is in use by another logger", dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lad: lock spool: %w", err)
+	}
+	q := &diskQueue{
+		dir:          dir,
+		max:          maxBytes,
+		segmentBytes: max(min(maxSpoolSegment, maxBytes/8), 1),
+		peekBytes:    defaultBufferBytes,
+		lock:         lock,
+	}
+	if err := q.replay(); err != nil {
+		_ = q.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+var errSpoolLocked = errors.New("lad: spool locked")
+
+// Close closes the segment files and releases the spool. Unacknowledged
+// records stay on disk for the next logger.
+func (q *diskQueue) Close() error {
+	var errs []error
+	if q.w != nil {
+		errs = append(errs, q.w.Close())
+		q.w = nil
+	}
+	if q.r != nil {
+		errs = append(errs, q.r.Close())
+		q.r = nil
+	}
+	return errors.Join(append(errs, q.lock.Close())...)
+}
+
+// replay loads the segments left by a previous process, drops acknowledged
+// ones, truncates torn records and starts a new segment for writing.
+func (q *diskQueue) replay() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("lad: read spool: %w", err)
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), spoolSegmentSuffix)
+		if !ok {
+			continue
+		}
+		if seq, err := strconv.ParseUint(name, 10, 64); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	cursor := q.readCursor()
+	next := uint64(1)
+	for _, seq := range seqs {
+		next = seq + 1
+		if seq < cursor.seq {
+			_ = os.Remove(q.segmentPath(seq))
+			continue
+		}
+		from := int64(0)
+		if seq == cursor.seq {
+			from = cursor.off
+		}
+		seg, err := q.scan(seq, from)
+		if err != nil {
+			return err
+		}
+		if seg.records == 0 {
+			_ = os.Remove(q.segmentPath(seq))
+			continue
+		}
+		if len(q.segs) == 0 {
+			q.ackOff = from
+		}
+		q.segs = append(q.segs, seg)
+		q.total += seg.size
+		q.count += seg.records
+		q.bytes += seg.bytes
+	}
+	if len(q.segs) > 0 {
+		q.readSeq, q.readOff = q.segs[0].seq, q.ackOff
+	}
+	if err := q.rotate(next); err != nil {
+		return err
+	}
+	q.writeCursor()
+	return nil
+}
+
+// scan counts the valid records of a segment from offset from, truncating
+// the file at the first torn or corrupt record.
+func (q *diskQueue) scan(seq uint64, from int64) (*spoolSegment, error) {
+	f, err := os.OpenFile(q.segmentPath(seq), os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("lad: open spool segment: %w", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("lad: open spool segment: %w", err)
+	}
+
+	seg := &spoolSegment{seq: seq}
+	off := int64(0)
+	for {
+		data, err := readSpoolRecord(f, off, fi.Size())
+		if err != nil {
+			if err != io.EOF {
+				_ = f.Truncate(off)
+			}
+			break
+		}
+		if off >= from {
+			seg.records++
+			seg.bytes += len(data)
+		}
+		off += spoolRecordHeader + int64(len(data))
+	}
+	seg.size = off
+	return seg, nil
+}
+
+func (q *diskQueue) push(rec []byte) bool {
+	need := int64(spoolRecordHeader + len(rec))
+	if need > q.max {
+		return false
+	}
+	active := q.segs[len(q.segs)-1]
+	if active.size > 0 && active.size+need > q.segmentBytes {
+		if q.rotate(active.seq+1) != nil {
+			return false
+		}
+		active = q.segs[len(q.segs)-1]
+	}
+	for q.total+need > q.max {
+		if !q.evict() {
+			return false
+		}
+	}
+
+	buf := make([]byte, spoolRecordHeader, need)
+	binary.BigEndian.PutUint32(buf, uint32(len(rec)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(rec))
+	buf = append(buf, rec...)
+	if _, err := q.w.Write(buf); err != nil {
+		// Cut a partial write so the segment stays readable.
+		_ = q.w.Truncate(active.size)
+		_, _ = q.w.Seek(active.size, io.SeekStart)
+		return false
+	}
+	active.size += need
+	active.records++
+	active.bytes += len(rec)
+	q.total += need
+	q.count++
+	q.bytes += len(rec)
+	return true
+}
+
+// evict removes the oldest segment that is neither written to nor holds
+// records being delivered.
+func (q *diskQueue) evict() bool {
+	busy := uint64(0) // segments up to busy hold pending records
+	if n := len(q.pendingEnd); n > 0 {
+		busy = q.pendingEnd[n-1].seq
+	}
+	for i, seg := range q.segs[:len(q.segs)-1] {
+		if len(q.pending) > 0 && seg.seq <= busy {
+			continue
+		}
+		if q.stats != nil {
+			q.stats.droppedEntries.Add(int64(seg.records))
+			q.stats.droppedBytes.Add(int64(seg.bytes))
+		}
+		q.count -= seg.records
+		q.bytes -= seg.bytes
+		q.remove(i)
+		if i == 0 {
+			q.ackOff = 0
+		}
+		if q.readSeq == seg.seq {
+			q.readSeq, q.readOff = q.segs[i].seq, 0
+		}
+		return true
+	}
+	return false
+}
+
+// peek reads at most peekBytes of records, however large the limits: unlike
+// a memQueue, the spool may hold far more than fits in memory.
+func (q *diskQueue) peek(maxRecords, maxBytes int) [][]byte {
+	if maxBytes <= 0 || maxBytes > q.peekBytes {
+		maxBytes = q.peekBytes
+	}
+	size := recordsSize(q.pending)
+	for maxRecords <= 0 || len(q.pending) < maxRecords {
+		data, pos, ok := q.read()
+		if !ok {
+			break
+		}
+		if maxBytes > 0 && len(q.pending) > 0 && size+len(data) > maxBytes {
+			break
+		}
+		q.pending = append(q.pending, data)
+		q.pendingEnd = append(q.pendingEnd, pos)
+		q.readSeq, q.readOff = pos.seq, pos.off
+		size += len(data)
+	}
+
+	// Pending records may exceed the limits after a partial peek.
+	n, size := 0, 0
+	for n < len(q.pending) {
+		if maxRecords > 0 && n == maxRecords {
+			break
+		}
+		if maxBytes > 0 && n > 0 && size+len(q.pending[n]) > maxBytes {
+			break
+		}
+		size += len(q.pending[n])
+		n++
+	}
+	return q.pending[:n:n]
+}
+
+// read returns the record at the read position and the position after it,
+// without advancing.
+func (q *diskQueue) read() ([]byte, spoolPos, bool) {
+	seq, off := q.readSeq, q.readOff
+	for i, seg := range q.segs {
+		if seg.seq < seq {
+			continue
+		}
+		if seg.seq > seq {
+			off = 0
+		}
+		if off >= seg.size {
+			continue
+		}
+		if q.r == nil || q.rSeq != seg.seq {
+			if q.r != nil {
+				_ = q.r.Close()
+			}
+			f, err := os.Open(q.segmentPath(seg.seq))
+			if err != nil {
+				q.r = nil
+				return nil, spoolPos{}, false
+			}
+			q.r, q.rSeq = f, seg.seq
+		}
+		data, err := readSpoolRecord(q.r, off, seg.size)
+		if err != nil {
+			// Unreadable remainder: skip to the next segment.
+			if i == len(q.segs)-1 {
+				return nil, spoolPos{}, false
+			}
+			continue
+		}
+		return data, spoolPos{seg.seq, off + spoolRecordHeader + int64(len(data))}, true
+	}
+	return nil, spoolPos{}, false
+}
+
+func (q *diskQueue) ack(n int) {
+	if n <= 0 {
+		return
+	}
+	end := q.pendingEnd[n-1]
+	for i, rec := range q.pending[:n] {
+		if seg := q.segment(q.pendingEnd[i].seq); seg != nil {
+			seg.records--
+			seg.bytes -= len(rec)
+		}
+		q.count--
+		q.bytes -= len(rec)
+	}
+	clear(q.pending[:n])
+	q.pending = q.pending[n:]
+	q.pendingEnd = q.pendingEnd[n:]
+
+	for len(q.segs) > 1 && q.segs[0].seq < end.seq {
+		q.remove(0)
+	}
+	q.ackOff = end.off
+	if len(q.segs) > 1 && q.ackOff >= q.segs[0].size {
+		q.remove(0)
+		q.ackOff = 0
+	}
+	q.writeCursor()
+}
+
+func (q *diskQueue) len() int  { return q.count }
+func (q *diskQueue) size() int { return q.bytes }
+
+func (q *diskQueue) segment(seq uint64) *spoolSegment {
+	for _, seg := range q.segs {
+		if seg.seq == seq {
+			return seg
+		}
+	}
+	return nil
+}
+
+// remove deletes segs[i], which must not be the active segment.
+func (q *diskQueue) remove(i int) {
+	seg := q.segs[i]
+	if q.r != nil && q.rSeq == seg.seq {
+		_ = q.r.Close()
+		q.r = nil
+	}
+	_ = os.Remove(q.segmentPath(seg.seq))
+	q.total -= seg.size
+	q.segs = append(q.segs[:i], q.segs[i+1:]...)
+}
+
+// rotate starts a new active segment.
+func (q *diskQueue) rotate(seq uint64) error {
+	f, err := os.OpenFile(q.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("lad: create spool segment: %w", err)
+	}
+	if q.w != nil {
+		_ = q.w.Close()
+	}
+	q.w = f
+	q.segs = append(q.segs, &spoolSegment{seq: seq})
+	if len(q.segs) == 1 {
+		q.readSeq, q.readOff = seq, 0
+	}
+	return nil
+}
+
+func (q *diskQueue) segmentPath(seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, spoolSegmentSuffix))
+}
+
+func (q *diskQueue) readCursor() spoolPos {
+	data, err := os.ReadFile(filepath.Join(q.dir, spoolCursorFilename))
+	if err != nil {
+		return spoolPos{}
+	}
+	var pos spoolPos
+	if _, err := fmt.Sscanf(string(data), "%d %d", &pos.seq, &pos.off); err != nil {
+		return spoolPos{}
+	}
+	return pos
+}
+
+// writeCursor records the acknowledged position. A lost update only causes
+// records to be delivered again.
+func (q *diskQueue) writeCursor() {
+	path := filepath.Join(q.dir, spoolCursorFilename)
+	tmp := path + ".tmp"
+	data := fmt.Sprintf("%d %d\n", q.segs[0].seq, q.ackOff)
+	if os.WriteFile(tmp, []byte(data), 0o644) == nil {
+		_ = os.Rename(tmp, path)
+	}
+}
+
+var errSpoolCorrupt = errors.New("lad: corrupt spool record")
+
+// readSpoolRecord reads the record at off of a segment holding size bytes.
+// It returns io.EOF at the end and errSpoolCorrupt for a torn or damaged
+// record.
+func readSpoolRecord(f *os.File, off, size int64) ([]byte, error) {
+	if off == size {
+		return nil, io.EOF
+	}
+	var hdr [spoolRecordHeader]byte
+	if off+spoolRecordHeader > size {
+		return nil, errSpoolCorrupt
+	}
+	if _, err := f.ReadAt(hdr[:], off); err != nil {
+		return nil, errSpoolCorrupt
+	}
+	n := int64(binary.BigEndian.Uint32(hdr[:]))
+	if off+spoolRecordHeader+n > size {
+		return nil, errSpoolCorrupt
+	}
+	data := make([]byte, n)
+	if _, err := f.ReadAt(data, off+spoolRecordHeader); err != nil {
+		return nil, errSpoolCorrupt
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(hdr[4:]) {
+		return nil, errSpoolCorrupt
+	}
+	return data, nil
+}
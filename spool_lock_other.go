@@ -0,0 +1,43 @@
+//go:build !unix
+
+package lad
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// lockedSpools holds the spools open in this process. Without flock, other
+// processes are not kept out.
+var lockedSpools sync.Map // absolute lock path -> struct{}
+
+// lockSpool takes an exclusive lock on the spool directory, held until the
+// returned file is closed, so two loggers never write the same spool.
+func lockSpool(path string) (io.Closer, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, loaded := lockedSpools.LoadOrStore(abs, struct{}{}); loaded {
+		return nil, errSpoolLocked
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		lockedSpools.Delete(abs)
+		return nil, err
+	}
+	return &spoolLock{File: f, abs: abs}, nil
+}
+
+type spoolLock struct {
+	*os.File
+	abs string
+}
+
+func (l *spoolLock) Close() error {
+	err := l.File.Close()
+	lockedSpools.Delete(l.abs)
+	return err
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package lad
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// lockSpool takes an exclusive lock on the spool directory, held until the
+// returned file is closed, so two loggers never write the same spool.
+func lockSpool(path string) (io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errSpoolLocked
+		}
+		return nil, err
+	}
+	return f, nil
+}
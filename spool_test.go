@@ -0,0 +1,165 @@
+package lad
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiskQueueReplaysUnacknowledged(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openDiskQueue(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for i := range 5 {
+		if !q.push([]byte(fmt.Sprintf("rec-%d", i))) {
+			t.Fatalf("push %d failed", i)
+		}
+	}
+	if got := q.peek(2, 0); len(got) != 2 || string(got[0]) != "rec-0" {
+		t.Fatalf("peek=%q", got)
+	}
+	q.ack(2)
+	// Peeked but unacknowledged records must come back after a restart.
+	_ = q.peek(1, 0)
+	if err := q.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	q, err = openDiskQueue(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if q.len() != 3 {
+		t.Fatalf("len=%d, want 3", q.len())
+	}
+	got := q.peek(0, 0)
+	want := []string{"rec-2", "rec-3", "rec-4"}
+	if len(got) != len(want) {
+		t.Fatalf("peek=%q, want %q", got, want)
+	}
+	for i := range want {
+		if string(got[i]) != want[i] {
+			t.Fatalf("peek=%q, want %q", got, want)
+		}
+	}
+	q.ack(3)
+	if q.len() != 0 || q.size() != 0 {
+		t.Fatalf("len=%d size=%d after ack", q.len(), q.size())
+	}
+
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(segs) != 1 {
+		t.Fatalf("segments=%v, want only the active one", segs)
+	}
+}
+
+func TestDiskQueueTruncatesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openDiskQueue(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	q.push([]byte("complete"))
+	q.push([]byte("torn"))
+	path := q.segmentPath(q.segs[len(q.segs)-1].seq)
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-2); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	_ = q.Close()
+
+	q, err = openDiskQueue(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got := q.peek(0, 0); len(got) != 1 || string(got[0]) != "complete" {
+		t.Fatalf("peek=%q", got)
+	}
+}
+
+func TestDiskQueueLocksDir(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openDiskQueue(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := openDiskQueue(dir, 1<<20); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Fatalf("second open err=%v, want in use", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	q, err = openDiskQueue(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("reopen after close: %v", err)
+	}
+	_ = q.Close()
+}
+
+func TestDiskQueuePeekIsBounded(t *testing.T) {
+	q, err := openDiskQueue(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer q.Close()
+	q.peekBytes = 25
+	for i := range 10 {
+		q.push([]byte(fmt.Sprintf("rec-%d", i))) // 5 bytes each
+	}
+	if got := q.peek(0, 0); len(got) != 5 {
+		t.Fatalf("unlimited peek returned %d records, want 5 (25 bytes)", len(got))
+	}
+	if got := q.peek(0, 1<<20); len(got) != 5 {
+		t.Fatalf("peek returned %d records, want 5 (25 bytes)", len(got))
+	}
+	q.ack(5)
+	if got := q.peek(0, 0); len(got) != 5 || string(got[0]) != "rec-5" {
+		t.Fatalf("peek after ack=%q", got)
+	}
+}
+
+func TestDiskQueueEvictsOldest(t *testing.T) {
+	stats := &SinkStats{}
+	q, err := openDiskQueue(t.TempDir(), 800) // 100 byte segments
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	q.stats = stats
+	rec := make([]byte, 42) // 50 bytes with the header: two per segment
+	for i := range 40 {
+		rec[0] = byte(i)
+		if !q.push(rec) {
+			t.Fatalf("push %d failed", i)
+		}
+	}
+	if q.total > q.max {
+		t.Fatalf("spool holds %d bytes, cap %d", q.total, q.max)
+	}
+	if stats.DroppedEntries() == 0 || int(stats.DroppedEntries())+q.len() != 40 {
+		t.Fatalf("dropped=%d len=%d", stats.DroppedEntries(), q.len())
+	}
+	head := q.peek(1, 0)[0][0]
+	if head != byte(stats.DroppedEntries()) {
+		t.Fatalf("head=%d, want the oldest surviving record %d", head, stats.DroppedEntries())
+	}
+
+	// The segment being delivered is not evicted.
+	for i := 40; i < 60; i++ {
+		rec[0] = byte(i)
+		q.push(rec)
+	}
+	if got := q.peek(1, 0)[0][0]; got != head {
+		t.Fatalf("pending record changed from %d to %d", head, got)
+	}
+	q.ack(1)
+	if next := q.peek(1, 0)[0][0]; next <= head {
+		t.Fatalf("next=%d after acknowledging %d", next, head)
+	}
+	if q.total > q.max {
+		t.Fatalf("spool holds %d bytes, cap %d", q.total, q.max)
+	}
+}
@@ -0,0 +1,130 @@
+package lad
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	streamQueue     = 256 // entries a consumer may lag behind before it is dropped
+	streamKeepAlive = 15 * time.Second
+)
+
+// Handler returns an http.Handler streaming entries written to rb as
+// Server-Sent Events, one event per entry with its encoded line as data:
+//
+//	curl -N 'http://localhost:6060/debug/logs?level=warn&logger=api&field=tenant=acme'
+//
+// Query parameters filter the stream: level (minimum level), logger (the
+// named logger and its children), field (key=value, repeatable; all must
+// match) and tail (also send the last N retained matching entries first).
+//
+// Consumers that fall behind are sent an "error" event and disconnected
+// rather than slowing down the logger.
+func (rb *RingBuffer) Handler() http.Handler {
+	return http.HandlerFunc(rb.serveStream)
+}
+
+func (rb *RingBuffer) serveStream(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStreamFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "lad: streaming is not supported by the server", http.StatusInternalServerError)
+		return
+	}
+
+	sub, backlog := rb.subscribe(filter, streamQueue)
+	defer rb.unsubscribe(sub)
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	for _, e := range backlog {
+		writeEvent(w, "", e.Line)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case e := <-sub.ch:
+			writeEvent(w, "", e.Line)
+			// Send what has queued up meanwhile in one flush.
+			for n := len(sub.ch); n > 0; n-- {
+				e = <-sub.ch
+				writeEvent(w, "", e.Line)
+			}
+		case <-sub.dropped:
+			writeEvent(w, "error", []byte("lad: consumer too slow, stream dropped"))
+			flusher.Flush()
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func parseStreamFilter(r *http.Request) (RingFilter, error) {
+	q := r.URL.Query()
+	var f RingFilter
+	if s := q.Get("level"); s != "" {
+		level, err := zapcore.ParseLevel(s)
+		if err != nil {
+			return f, fmt.Errorf("lad: invalid level %q", s)
+		}
+		f.Level = level
+	}
+	f.Logger = q.Get("logger")
+	var matchers []RouteMatcher
+	for _, kv := range q["field"] {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return f, fmt.Errorf("lad: invalid field filter %q, want key=value", kv)
+		}
+		matchers = append(matchers, MatchField(key, value))
+	}
+	if len(matchers) > 0 {
+		f.Match = MatchAll(matchers...)
+	}
+	if s := q.Get("tail"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return f, fmt.Errorf("lad: invalid tail %q", s)
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
+
+// writeEvent writes an SSE event; data spanning several lines (such as a
+// console-encoded stack trace) becomes several data fields.
+func writeEvent(w http.ResponseWriter, event string, data []byte) {
+	var buf bytes.Buffer
+	if event != "" {
+		buf.WriteString("event: " + event + "\n")
+	}
+	for _, line := range bytes.Split(bytes.TrimRight(data, "\r\n"), []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(bytes.TrimSuffix(line, []byte("\r")))
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	_, _ = w.Write(buf.Bytes())
+}
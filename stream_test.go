@@ -0,0 +1,89 @@
+package lad
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRingBufferHandler(t *testing.T) {
+	rb := NewRingBuffer(16)
+	l, err := New(WithRingBuffer(RingBufferConfig{Level: zapcore.DebugLevel, Buffer: rb}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Warn("before", String("tenant", "acme"))
+
+	srv := httptest.NewServer(rb.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?level=warn&field=tenant=acme&tail=5")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type=%q", ct)
+	}
+
+	events := make(chan string, 8)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+				events <- data
+			}
+		}
+		close(events)
+	}()
+
+	next := func() string {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for an event")
+			return ""
+		}
+	}
+	if e := next(); !strings.Contains(e, `"msg":"before"`) {
+		t.Fatalf("tail event=%q", e)
+	}
+
+	l.Error("other tenant", String("tenant", "globex"))
+	l.Info("too low", String("tenant", "acme"))
+	l.Error("live", String("tenant", "acme"))
+	if e := next(); !strings.Contains(e, `"msg":"live"`) {
+		t.Fatalf("live event=%q", e)
+	}
+}
+
+func TestRingBufferHandlerBadFilter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewRingBuffer(1).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?level=loud", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status=%d, want 400", rec.Code)
+	}
+}
+
+func TestRingBufferDropsSlowSubscriber(t *testing.T) {
+	rb := NewRingBuffer(4)
+	l, err := New(WithRingBuffer(RingBufferConfig{Buffer: rb}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	sub, _ := rb.subscribe(RingFilter{}, 1)
+	l.Info("one")
+	l.Info("two") // does not block
+	select {
+	case <-sub.dropped:
+	default:
+		t.Fatal("slow subscriber was not dropped")
+	}
+}
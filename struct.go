@@ -0,0 +1,375 @@
+package lad
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted replaces the values of struct fields tagged with "redact".
+const Redacted = "[REDACTED]"
+
+// Struct constructs a field that encodes a struct (or a pointer to one) as an
+// object, following `log` struct tags:
+//
+//	type User struct {
+//		ID       int64  `log:"id"`
+//		Email    string `log:"email,redact"`
+//		Nickname string `log:",omitempty"`
+//		Password string `log:"-"`
+//	}
+//
+// The tag names the key (the Go field name when empty); "omitempty" skips zero
+// values, "redact" logs Redacted instead of the value (and keeps the key), and
+// "-" skips the field. Embedded structs without a name are flattened.
+// Unexported fields are skipped.
+//
+// The layout of each type is computed once and cached, so encoding avoids the
+// cost of Reflect. Values implementing zapcore.ObjectMarshaler or
+// zapcore.ArrayMarshaler are encoded through those methods. A pointer, map
+// or slice found inside itself is logged as "<cycle>" instead.
+func Struct(key string, val any) Field {
+	if m, ok := val.(zapcore.ObjectMarshaler); ok {
+		return Object(key, m)
+	}
+	rv := reflect.ValueOf(val)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nilField(key)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return Any(key, val)
+	}
+	return Object(key, structMarshaler{rv, nil})
+}
+
+// StructObject returns an ObjectMarshaler encoding val like Struct, e.g. to
+// add a struct's fields to the current namespace with Inline:
+//
+//	logger.Info("login", lad.Inline(lad.StructObject(user)))
+func StructObject(val any) zapcore.ObjectMarshaler {
+	rv := reflect.ValueOf(val)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			return enc.AddReflected("value", val)
+		})
+	}
+	return structMarshaler{rv, nil}
+}
+
+type structMarshaler struct {
+	v    reflect.Value
+	path valuePath
+}
+
+func (s structMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range structPlanOf(s.v.Type()) {
+		fv := s.v.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if f.redact {
+			enc.AddString(f.name, Redacted)
+			continue
+		}
+		if err := addValue(enc, f.name, fv, s.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	redact    bool
+}
+
+var structPlans sync.Map // reflect.Type -> []structField
+
+func structPlanOf(t reflect.Type) []structField {
+	if plan, ok := structPlans.Load(t); ok {
+		return plan.([]structField)
+	}
+	plan, _ := structPlans.LoadOrStore(t, buildStructPlan(t, nil))
+	return plan.([]structField)
+}
+
+func buildStructPlan(t reflect.Type, index []int) []structField {
+	var plan []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("log")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		idx := append(index[:len(index):len(index)], i)
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Struct {
+				plan = append(plan, buildStructPlan(ft, idx)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		f := structField{name: name, index: idx}
+		if hasTag {
+			for _, opt := range strings.Split(opts, ",") {
+				switch strings.TrimSpace(opt) {
+				case "omitempty":
+					f.omitEmpty = true
+				case "redact":
+					f.redact = true
+				}
+			}
+		}
+		plan = append(plan, f)
+	}
+	return plan
+}
+
+var (
+	objectMarshalerType = reflect.TypeFor[zapcore.ObjectMarshaler]()
+	arrayMarshalerType  = reflect.TypeFor[zapcore.ArrayMarshaler]()
+	errorType           = reflect.TypeFor[error]()
+	stringerType        = reflect.TypeFor[fmt.Stringer]()
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+)
+
+// cycleValue replaces a value found inside itself.
+const cycleValue = "<cycle>"
+
+// valuePath holds the pointers, maps and slices being encoded, like
+// encoding/json does, so a value containing itself is cut short instead of
+// overflowing the stack. It is created on first use by each encoding.
+type valuePath map[visit]struct{}
+
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// enter adds v to p, creating p if nil, and returns p and the key to delete
+// once v is encoded. It reports false if v is a pointer, map or slice
+// already on the path; other values are not tracked.
+func (p valuePath) enter(v reflect.Value) (valuePath, visit, bool) {
+	var k visit
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map:
+		if v.IsNil() {
+			return p, visit{}, true
+		}
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return p, visit{}, true
+		}
+		k.len = v.Len()
+	default:
+		return p, visit{}, true
+	}
+	k.ptr, k.typ = v.Pointer(), v.Type()
+	if _, ok := p[k]; ok {
+		return p, visit{}, false
+	}
+	if p == nil {
+		p = make(valuePath)
+	}
+	p[k] = struct{}{}
+	return p, k, true
+}
+
+// addValue adds v under key, dispatching on its type without going through
+// encoding/json. path holds the values v is part of.
+func addValue(enc zapcore.ObjectEncoder, key string, v reflect.Value, path valuePath) error {
+	if m, ok := marshaler(v); ok {
+		switch m := m.(type) {
+		case zapcore.ObjectMarshaler:
+			return enc.AddObject(key, m)
+		case zapcore.ArrayMarshaler:
+			return enc.AddArray(key, m)
+		case time.Time:
+			enc.AddTime(key, m)
+		case time.Duration:
+			enc.AddDuration(key, m)
+		case error:
+			enc.AddString(key, m.Error())
+		case fmt.Stringer:
+			enc.AddString(key, m.String())
+		}
+		return nil
+	}
+
+	path, visited, ok := path.enter(v)
+	if !ok {
+		enc.AddString(key, cycleValue)
+		return nil
+	}
+	defer delete(path, visited)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		enc.AddBool(key, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.AddInt64(key, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		enc.AddUint64(key, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		enc.AddFloat64(key, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		enc.AddComplex128(key, v.Complex())
+	case reflect.String:
+		enc.AddString(key, v.String())
+	case reflect.Struct:
+		return enc.AddObject(key, structMarshaler{v, path})
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+		return addValue(enc, key, v.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			enc.AddBinary(key, v.Bytes())
+			return nil
+		}
+		return enc.AddArray(key, valueArray{v, path})
+	case reflect.Map:
+		if v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+		return enc.AddObject(key, valueMap{v, path})
+	default:
+		// Channels, functions and unsafe pointers carry nothing to log.
+	}
+	return nil
+}
+
+// marshaler returns v as a value with dedicated encoding, if it has one.
+func marshaler(v reflect.Value) (any, bool) {
+	t := v.Type()
+	switch {
+	case !v.CanInterface():
+		// Promoted through an unexported embedded struct: encode by kind.
+		return nil, false
+	case t == timeType, t == durationType:
+		return v.Interface(), true
+	case t.Kind() == reflect.Interface || t.Kind() == reflect.Pointer && v.IsNil():
+		// Resolved on the dynamic value, or encoded as null.
+		return nil, false
+	case t.Implements(objectMarshalerType), t.Implements(arrayMarshalerType),
+		t.Implements(errorType), t.Implements(stringerType):
+		return v.Interface(), true
+	case v.CanAddr() && reflect.PointerTo(t).Implements(objectMarshalerType):
+		return v.Addr().Interface(), true
+	}
+	return nil, false
+}
+
+type valueArray struct {
+	v    reflect.Value
+	path valuePath
+}
+
+func (a valueArray) MarshalLogArray(arr zapcore.ArrayEncoder) error {
+	for i := 0; i < a.v.Len(); i++ {
+		if err := appendValue(arr, a.v.Index(i), a.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendValue is addValue for array elements.
+func appendValue(arr zapcore.ArrayEncoder, v reflect.Value, path valuePath) error {
+	if m, ok := marshaler(v); ok {
+		switch m := m.(type) {
+		case zapcore.ObjectMarshaler:
+			return arr.AppendObject(m)
+		case zapcore.ArrayMarshaler:
+			return arr.AppendArray(m)
+		case time.Time:
+			arr.AppendTime(m)
+		case time.Duration:
+			arr.AppendDuration(m)
+		case error:
+			arr.AppendString(m.Error())
+		case fmt.Stringer:
+			arr.AppendString(m.String())
+		}
+		return nil
+	}
+
+	path, visited, ok := path.enter(v)
+	if !ok {
+		arr.AppendString(cycleValue)
+		return nil
+	}
+	defer delete(path, visited)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		arr.AppendBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		arr.AppendInt64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		arr.AppendUint64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		arr.AppendFloat64(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		arr.AppendComplex128(v.Complex())
+	case reflect.String:
+		arr.AppendString(v.String())
+	case reflect.Struct:
+		return arr.AppendObject(structMarshaler{v, path})
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return arr.AppendReflected(nil)
+		}
+		return appendValue(arr, v.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			arr.AppendString(base64.StdEncoding.EncodeToString(v.Bytes()))
+			return nil
+		}
+		return arr.AppendArray(valueArray{v, path})
+	case reflect.Map:
+		if v.IsNil() {
+			return arr.AppendReflected(nil)
+		}
+		return arr.AppendObject(valueMap{v, path})
+	}
+	return nil
+}
+
+type valueMap struct {
+	v    reflect.Value
+	path valuePath
+}
+
+// MarshalLogObject adds the entries in key order, so output is deterministic.
+func (m valueMap) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	entries := make([]mapEntry, 0, m.v.Len())
+	for it := m.v.MapRange(); it.Next(); {
+		entries = append(entries, mapEntry{key: it.Key(), rval: it.Value()})
+	}
+	return addMapEntries(enc, entries, m.path)
+}
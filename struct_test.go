@@ -0,0 +1,123 @@
+package lad
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type auditMeta struct {
+	Source string `log:"source"`
+	secret string
+}
+
+type account struct {
+	auditMeta
+	ID       int64             `log:"id"`
+	Email    string            `log:"email,redact"`
+	Nickname string            `log:",omitempty"`
+	Password string            `log:"-"`
+	Tags     []string          `log:"tags"`
+	Limits   map[string]int    `log:"limits"`
+	Owner    *account          `log:"owner,omitempty"`
+	Created  time.Time         `log:"created"`
+	TTL      time.Duration     `log:"ttl"`
+	Points   []point           `log:"points"`
+	Labels   map[string]string `log:"labels,omitempty"`
+}
+
+func TestStruct(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	acct := &account{
+		auditMeta: auditMeta{Source: "api", secret: "x"},
+		ID:        7,
+		Email:     "a@example.com",
+		Password:  "hunter2",
+		Tags:      []string{"vip"},
+		Limits:    map[string]int{"b": 2, "a": 1},
+		Owner:     &account{ID: 1},
+		Created:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		TTL:       2 * time.Second,
+		Points:    []point{{1, 2}},
+	}
+	l.Info("saved", Struct("account", acct), Inline(StructObject(auditMeta{Source: "inline"})))
+
+	var entry map[string]any
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("decode %q: %v", out.String(), err)
+	}
+	got, _ := entry["account"].(map[string]any)
+	want := map[string]any{
+		"source":  "api",
+		"id":      float64(7),
+		"email":   Redacted,
+		"tags":    []any{"vip"},
+		"limits":  map[string]any{"a": float64(1), "b": float64(2)},
+		"ttl":     float64(2),
+		"points":  []any{map[string]any{"x": float64(1), "y": float64(2)}},
+		"created": "2024-01-02 03:04:05.000",
+	}
+	for k, v := range want {
+		if g, _ := json.Marshal(got[k]); string(g) != mustJSON(v) {
+			t.Errorf("%s=%s, want %s", k, g, mustJSON(v))
+		}
+	}
+	for _, k := range []string{"Nickname", "Password", "secret", "labels"} {
+		if _, ok := got[k]; ok {
+			t.Errorf("unexpected key %q in %v", k, got)
+		}
+	}
+	if owner, _ := got["owner"].(map[string]any); owner["id"] != float64(1) {
+		t.Errorf("owner=%v", got["owner"])
+	}
+	if entry["source"] != "inline" {
+		t.Errorf("inline source=%v", entry["source"])
+	}
+}
+
+type cyclicNode struct {
+	Name  string        `log:"name"`
+	Next  *cyclicNode   `log:"next"`
+	Peers []*cyclicNode `log:"peers"`
+	Any   any           `log:"any"`
+}
+
+func TestStructCycle(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(WithWriter(WriterConfig{Writer: &out}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	n := &cyclicNode{Name: "a"}
+	n.Next, n.Peers, n.Any = n, []*cyclicNode{n}, n
+	shared := &cyclicNode{Name: "shared"}
+	l.Info("cycle", Struct("node", n), Map("siblings", map[string]*cyclicNode{"a": shared, "b": shared}))
+
+	var entry map[string]any
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("decode %q: %v", out.String(), err)
+	}
+	next := mustJSON(map[string]any{"name": "a", "next": cycleValue, "peers": []any{cycleValue}, "any": cycleValue})
+	node, _ := entry["node"].(map[string]any)
+	if got := mustJSON(node["next"]); got != next {
+		t.Errorf("next=%s, want %s", got, next)
+	}
+	if got := mustJSON(node["any"]); got != next {
+		t.Errorf("any=%s, want %s", got, next)
+	}
+	// A value seen twice, but not inside itself, is no cycle.
+	if got := mustJSON(entry["siblings"]); strings.Contains(got, cycleValue) {
+		t.Errorf("siblings=%s", got)
+	}
+}
+
+func mustJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,487 @@
+package lad
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SyslogFormat selects the syslog message format.
+type SyslogFormat string
+
+const (
+	// RFC5424Format writes RFC 5424 messages with fields as structured data (default).
+	RFC5424Format SyslogFormat = "rfc5424"
+	// RFC3164Format writes legacy BSD messages with fields appended as JSON.
+	RFC3164Format SyslogFormat = "rfc3164"
+)
+
+// SyslogFacility is a syslog facility code.
+type SyslogFacility int
+
+const (
+	SyslogUser   SyslogFacility = 1
+	SyslogMail   SyslogFacility = 2
+	SyslogDaemon SyslogFacility = 3
+	SyslogAuth   SyslogFacility = 4
+	SyslogLocal0 SyslogFacility = 16
+	SyslogLocal1 SyslogFacility = 17
+	SyslogLocal2 SyslogFacility = 18
+	SyslogLocal3 SyslogFacility = 19
+	SyslogLocal4 SyslogFacility = 20
+	SyslogLocal5 SyslogFacility = 21
+	SyslogLocal6 SyslogFacility = 22
+	SyslogLocal7 SyslogFacility = 23
+)
+
+// DefaultSyslogSDID is the structured data ID used for fields in RFC 5424 messages.
+const DefaultSyslogSDID = "lad@32473"
+
+// SyslogConfig controls syslog output.
+type SyslogConfig struct {
+	Level   zapcore.Level
+	Enabler zapcore.LevelEnabler // Overrides Level when set (see LevelRange).
+
+	// Network is "unix", "udp" or "tcp". When both Network and Address are
+	// empty, the local syslog socket (/dev/log and friends) is used.
+	Network string
+	Address string
+
+	Format   SyslogFormat   // Defaults to RFC5424Format.
+	Facility SyslogFacility // Defaults to SyslogUser.
+	AppName  string         // Defaults to the executable name.
+	Hostname string         // Defaults to os.Hostname().
+	SDID     string         // Defaults to DefaultSyslogSDID.
+
+	// DialTimeout bounds connecting and, as the write deadline, each write,
+	// so an unreachable or stalled daemon can't block logging calls for
+	// longer. Defaults to 5s.
+	DialTimeout time.Duration
+}
+
+// WithSyslog adds a core writing to a syslog daemon. Levels map to syslog
+// severities (Debug=7 ... Fatal=0). TCP uses octet-counting framing (RFC 6587).
+// The connection is established lazily and re-established after failures;
+// after a failed dial, writes fail fast for a second before dialing again.
+func WithSyslog(sc SyslogConfig) Option {
+	return func(c *config) error {
+		c.coreBuilders = append(c.coreBuilders, func(cfg *config) (zapcore.Core, error) {
+			format := sc.Format
+			switch format {
+			case "":
+				format = RFC5424Format
+			case RFC5424Format, RFC3164Format:
+			default:
+				return nil, fmt.Errorf("lad: unknown SyslogFormat %q", format)
+			}
+
+			timeout := sc.DialTimeout
+			if timeout <= 0 {
+				timeout = defaultSyslogTimeout
+			}
+			dial, framing, err := syslogDialer(sc.Network, sc.Address, timeout)
+			if err != nil {
+				return nil, err
+			}
+
+			facility := sc.Facility
+			if facility == 0 {
+				facility = SyslogUser
+			}
+			if facility < 0 || facility > SyslogLocal7 {
+				return nil, fmt.Errorf("lad: invalid SyslogFacility %d", facility)
+			}
+
+			hostname := sc.Hostname
+			if hostname == "" {
+				hostname, _ = os.Hostname()
+			}
+			appName := sc.AppName
+			if appName == "" {
+				appName = filepath.Base(os.Args[0])
+			}
+
+			f := &syslogFormatter{
+				format:   format,
+				facility: facility,
+				hostname: orDefault(syslogToken(hostname, 255), "-"),
+				appName:  orDefault(syslogToken(appName, 48), "-"),
+				procID:   strconv.Itoa(os.Getpid()),
+				sdID:     orDefault(syslogToken(sc.SDID, 32), DefaultSyslogSDID),
+				location: cfg.location,
+				caller:   cfg.callerEncode,
+			}
+			conn := &redialConn{dial: dial, timeout: timeout}
+			cfg.closers = append(cfg.closers, conn)
+			core := &syslogCore{
+				LevelEnabler: levelEnabler(sc.Enabler, sc.Level),
+				formatter:    f,
+				conn:         conn,
+				framing:      framing,
+			}
+			return core, nil
+		})
+		return nil
+	}
+}
+
+const (
+	defaultSyslogTimeout = 5 * time.Second
+	syslogRedialDelay    = time.Second // how long writes fail fast after a failed dial
+)
+
+type syslogFraming int
+
+const (
+	datagramFraming syslogFraming = iota // one message per packet
+	newlineFraming                       // stream, newline terminated
+	octetFraming                         // stream, "LEN SP MSG"
+)
+
+func syslogDialer(network, address string, timeout time.Duration) (func() (net.Conn, error), syslogFraming, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	switch network {
+	case "":
+		if address != "" {
+			return nil, 0, errors.New("lad: SyslogConfig.Network is required with Address")
+		}
+		return func() (net.Conn, error) { return dialLocalSyslog(dialer) }, datagramFraming, nil
+	case "unix", "unixgram":
+		if address == "" {
+			address = "/dev/log"
+		}
+		return func() (net.Conn, error) { return dialUnixSyslog(dialer, address) }, datagramFraming, nil
+	case "udp", "udp4", "udp6":
+		if address == "" {
+			return nil, 0, errors.New("lad: SyslogConfig.Address is required for udp")
+		}
+		return func() (net.Conn, error) { return dialer.Dial(network, address) }, datagramFraming, nil
+	case "tcp", "tcp4", "tcp6":
+		if address == "" {
+			return nil, 0, errors.New("lad: SyslogConfig.Address is required for tcp")
+		}
+		return func() (net.Conn, error) { return dialer.Dial(network, address) }, octetFraming, nil
+	default:
+		return nil, 0, fmt.Errorf("lad: unsupported syslog network %q", network)
+	}
+}
+
+func dialLocalSyslog(dialer *net.Dialer) (net.Conn, error) {
+	var errs []error
+	for _, path := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+		conn, err := dialUnixSyslog(dialer, path)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("lad: no local syslog socket: %w", errors.Join(errs...))
+}
+
+// dialUnixSyslog prefers datagram sockets and falls back to stream sockets.
+func dialUnixSyslog(dialer *net.Dialer, path string) (net.Conn, error) {
+	conn, err := dialer.Dial("unixgram", path)
+	if err == nil {
+		return conn, nil
+	}
+	conn, err2 := dialer.Dial("unix", path)
+	if err2 == nil {
+		return &framedConn{Conn: conn, framing: newlineFraming}, nil
+	}
+	return nil, err
+}
+
+// framedConn overrides the framing of the dialed connection, used when a
+// unix path turns out to be a stream socket.
+type framedConn struct {
+	net.Conn
+	framing syslogFraming
+}
+
+// redialConn is a connection that dials lazily and redials once when a write
+// fails, so a restarted daemon does not silence the logger. Dials and writes
+// are bounded by timeout, and a failed dial isn't retried before
+// syslogRedialDelay has passed, so logging calls don't pile up behind an
+// unreachable daemon. After Close, messages are dropped.
+type redialConn struct {
+	dial    func() (net.Conn, error)
+	timeout time.Duration
+
+	mu       sync.Mutex
+	conn     net.Conn
+	nextDial time.Time
+	dialErr  error
+	closed   bool
+}
+
+// Close closes the connection and stops redialing.
+func (rc *redialConn) Close() error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.closed = true
+	if rc.conn == nil {
+		return nil
+	}
+	err := rc.conn.Close()
+	rc.conn = nil
+	return err
+}
+
+func (rc *redialConn) write(framing syslogFraming, msg []byte) error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.closed {
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if rc.conn == nil {
+			if time.Now().Before(rc.nextDial) {
+				return rc.dialErr
+			}
+			if rc.conn, err = rc.dial(); err != nil {
+				rc.conn = nil
+				rc.nextDial, rc.dialErr = time.Now().Add(syslogRedialDelay), err
+				return err
+			}
+		}
+		f := framing
+		if fc, ok := rc.conn.(*framedConn); ok {
+			f = fc.framing
+		}
+		if rc.timeout > 0 {
+			_ = rc.conn.SetWriteDeadline(time.Now().Add(rc.timeout))
+		}
+		if _, err = rc.conn.Write(frameSyslog(f, msg)); err == nil {
+			return nil
+		}
+		_ = rc.conn.Close()
+		rc.conn = nil
+	}
+	return err
+}
+
+func frameSyslog(framing syslogFraming, msg []byte) []byte {
+	switch framing {
+	case octetFraming:
+		return append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	case newlineFraming:
+		return append(msg, '\n')
+	default:
+		return msg
+	}
+}
+
+type syslogCore struct {
+	zapcore.LevelEnabler
+	formatter *syslogFormatter
+	conn      *redialConn
+	framing   syslogFraming
+	fields    []Field
+}
+
+func (sc *syslogCore) With(fields []Field) zapcore.Core {
+	clone := *sc
+	clone.fields = append(sc.fields[:len(sc.fields):len(sc.fields)], fields...)
+	return &clone
+}
+
+func (sc *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if sc.Enabled(ent.Level) {
+		return ce.AddCore(ent, sc)
+	}
+	return ce
+}
+
+func (sc *syslogCore) Write(ent zapcore.Entry, fields []Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range sc.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	msg := sc.formatter.encode(ent, enc.Fields)
+	if err := sc.conn.write(sc.framing, msg); err != nil {
+		return fmt.Errorf("lad: write syslog: %w", err)
+	}
+	return nil
+}
+
+// Sync is a no-op: messages are not buffered.
+func (sc *syslogCore) Sync() error { return nil }
+
+type syslogFormatter struct {
+	format   SyslogFormat
+	facility SyslogFacility
+	hostname string
+	appName  string
+	procID   string
+	sdID     string
+	location *time.Location
+	caller   zapcore.CallerEncoder
+}
+
+func (f *syslogFormatter) encode(ent zapcore.Entry, fields map[string]any) []byte {
+	params := flattenFields("", fields, f.location, nil)
+	if ent.Caller.Defined && f.caller != nil {
+		arr := &stringArrayEncoder{}
+		f.caller(ent.Caller, arr)
+		params = append(params, [2]string{"caller", strings.Join(arr.elems, "")})
+	}
+	if ent.Stack != "" {
+		params = append(params, [2]string{"stacktrace", ent.Stack})
+	}
+
+	t := ent.Time
+	if f.location != nil {
+		t = t.In(f.location)
+	}
+	pri := int(f.facility)*8 + syslogSeverity(ent.Level)
+
+	var b strings.Builder
+	if f.format == RFC3164Format {
+		fmt.Fprintf(&b, "<%d>%s %s %s[%s]: %s", pri, t.Format(time.Stamp), f.hostname, f.appName, f.procID, ent.Message)
+		if len(params) > 0 {
+			obj := make(map[string]string, len(params))
+			for _, p := range params {
+				obj[p[0]] = p[1]
+			}
+			data, _ := json.Marshal(obj)
+			b.WriteByte(' ')
+			b.Write(data)
+		}
+		return []byte(b.String())
+	}
+
+	msgID := orDefault(syslogToken(ent.LoggerName, 32), "-")
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s ", pri, t.Format("2006-01-02T15:04:05.000000Z07:00"), f.hostname, f.appName, f.procID, msgID)
+	if len(params) == 0 {
+		b.WriteByte('-')
+	} else {
+		b.WriteString("[" + f.sdID)
+		for _, p := range params {
+			fmt.Fprintf(&b, " %s=\"%s\"", orDefault(syslogToken(p[0], 32), "_"), sdEscaper.Replace(p[1]))
+		}
+		b.WriteByte(']')
+	}
+	b.WriteByte(' ')
+	b.WriteString(ent.Message)
+	return []byte(b.String())
+}
+
+func syslogSeverity(l zapcore.Level) int {
+	switch {
+	case l <= zapcore.DebugLevel:
+		return 7
+	case l == zapcore.InfoLevel:
+		return 6
+	case l == zapcore.WarnLevel:
+		return 4
+	case l == zapcore.ErrorLevel:
+		return 3
+	case l == zapcore.DPanicLevel:
+		return 2
+	case l == zapcore.PanicLevel:
+		return 1
+	default:
+		return 0
+	}
+}
+
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogToken keeps printable US-ASCII without spaces or SD delimiters, as
+// required for header fields and SD-NAMEs, truncated to max bytes.
+func syslogToken(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
+
+// flattenFields turns nested field maps into sorted dotted key/value pairs.
+// Times are shown in loc, when set, like the entry time.
+func flattenFields(prefix string, fields map[string]any, loc *time.Location, dst [][2]string) [][2]string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		val := fields[k]
+		if p, ok := val.(plainValuer); ok {
+			val = p.plainValue()
+		}
+		switch v := val.(type) {
+		case map[string]any:
+			dst = flattenFields(key, v, loc, dst)
+		case string:
+			dst = append(dst, [2]string{key, v})
+		case time.Time:
+			if loc != nil {
+				v = v.In(loc)
+			}
+			dst = append(dst, [2]string{key, v.Format(time.RFC3339Nano)})
+		case []any:
+			data, err := json.Marshal(v)
+			if err != nil {
+				data = []byte(fmt.Sprint(v))
+			}
+			dst = append(dst, [2]string{key, string(data)})
+		default:
+			dst = append(dst, [2]string{key, fmt.Sprint(v)})
+		}
+	}
+	return dst
+}
+
+// stringArrayEncoder collects the output of a primitive encoder (such as a
+// CallerEncoder) as strings.
+type stringArrayEncoder struct {
+	elems []string
+}
+
+func (s *stringArrayEncoder) AppendBool(v bool)              { s.elems = append(s.elems, strconv.FormatBool(v)) }
+func (s *stringArrayEncoder) AppendByteString(v []byte)      { s.elems = append(s.elems, string(v)) }
+func (s *stringArrayEncoder) AppendComplex128(v complex128)  { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendComplex64(v complex64)    { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendFloat64(v float64)        { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendFloat32(v float32)        { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendInt(v int)                { s.elems = append(s.elems, strconv.Itoa(v)) }
+func (s *stringArrayEncoder) AppendInt64(v int64)            { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendInt32(v int32)            { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendInt16(v int16)            { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendInt8(v int8)              { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendString(v string)          { s.elems = append(s.elems, v) }
+func (s *stringArrayEncoder) AppendUint(v uint)              { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendUint64(v uint64)          { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendUint32(v uint32)          { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendUint16(v uint16)          { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendUint8(v uint8)            { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendUintptr(v uintptr)        { s.elems = append(s.elems, fmt.Sprint(v)) }
+func (s *stringArrayEncoder) AppendDuration(v time.Duration) { s.elems = append(s.elems, v.String()) }
+func (s *stringArrayEncoder) AppendTime(v time.Time) {
+	s.elems = append(s.elems, v.Format(time.RFC3339Nano))
+}
@@ -0,0 +1,222 @@
+package lad
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+
+	l, err := New(WithSyslog(SyslogConfig{
+		Network:  "udp",
+		Address:  pc.LocalAddr().String(),
+		Facility: SyslogLocal0,
+		AppName:  "svc",
+		Hostname: "host1",
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Named("api").With(String("req", `a"b`)).Warn("slow request", Int("ms", 1200))
+
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	msg := string(buf[:n])
+
+	// local0 (16) * 8 + warning (4) = 132
+	if !strings.HasPrefix(msg, "<132>1 ") {
+		t.Fatalf("msg=%q, want <132>1 prefix", msg)
+	}
+	for _, want := range []string{" host1 svc ", " api [lad@32473 ", `ms="1200"`, `req="a\"b"`, "] slow request"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("msg=%q, want it to contain %q", msg, want)
+		}
+	}
+}
+
+func TestWithSyslogTCPReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	msgs := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					size, err := r.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(size))
+					frame := make([]byte, n)
+					if _, err := r.Read(frame); err != nil {
+						return
+					}
+					msgs <- string(frame)
+					conn.Close() // force the client to reconnect
+				}
+			}(conn)
+		}
+	}()
+
+	l, err := New(WithSyslog(SyslogConfig{
+		Level:   zapcore.DebugLevel,
+		Network: "tcp",
+		Address: ln.Addr().String(),
+		Format:  RFC3164Format,
+	}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+
+	for i, text := range []string{"first", "second"} {
+		deadline := time.After(2 * time.Second)
+		for got := false; !got; {
+			l.Info(text, Int("n", i))
+			select {
+			case msg := <-msgs:
+				if !strings.Contains(msg, text) {
+					continue // a retried earlier message
+				}
+				if !strings.HasPrefix(msg, "<14>") || !strings.Contains(msg, text+` {"n":"`+strconv.Itoa(i)+`"}`) {
+					t.Fatalf("msg=%q", msg)
+				}
+				got = true
+			case <-time.After(50 * time.Millisecond):
+			case <-deadline:
+				t.Fatalf("no %q message received", text)
+			}
+		}
+	}
+}
+
+func TestSyslogWriteTimeout(t *testing.T) {
+	dials := 0
+	rc := &redialConn{
+		dial: func() (net.Conn, error) {
+			dials++
+			conn, _ := net.Pipe() // nobody reads the other end
+			return conn, nil
+		},
+		timeout: 20 * time.Millisecond,
+	}
+	start := time.Now()
+	if err := rc.write(octetFraming, []byte("stalled")); err == nil {
+		t.Fatal("write to a stalled peer succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("write blocked for %v", elapsed)
+	}
+	if dials != 2 {
+		t.Fatalf("dials=%d, want 2 (one redial)", dials)
+	}
+}
+
+func TestSyslogDialFailsFast(t *testing.T) {
+	dials := 0
+	rc := &redialConn{
+		dial: func() (net.Conn, error) {
+			dials++
+			return nil, errors.New("unreachable")
+		},
+		timeout: time.Second,
+	}
+	for i := 0; i < 3; i++ {
+		if err := rc.write(octetFraming, []byte("msg")); err == nil {
+			t.Fatal("write without a connection succeeded")
+		}
+	}
+	if dials != 1 {
+		t.Fatalf("dials=%d, want 1 within the redial delay", dials)
+	}
+}
+
+func TestSyslogClose(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	l, err := New(WithSyslog(SyslogConfig{Network: "tcp", Address: ln.Addr().String()}))
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	l.Info("before close")
+	var conn net.Conn
+	select {
+	case conn = <-accepted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no connection")
+	}
+	defer conn.Close()
+	if err := Close(l); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	l.Info("after close")
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	data, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("connection not closed: %v", err)
+	}
+	if !strings.Contains(string(data), "before close") || strings.Contains(string(data), "after close") {
+		t.Fatalf("received %q", data)
+	}
+	select {
+	case <-accepted:
+		t.Fatal("redialed after Close")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSyslogTimeFieldsUseLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+9", 9*3600)
+	f := &syslogFormatter{format: RFC5424Format, location: loc, sdID: DefaultSyslogSDID}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := string(f.encode(zapcore.Entry{Time: at, Message: "m"}, map[string]any{
+		"at":     at,
+		"nested": map[string]any{"at": at},
+	}))
+	for _, want := range []string{`at="2024-01-02T12:04:05+09:00"`, `nested.at="2024-01-02T12:04:05+09:00"`} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("msg=%q, want it to contain %q", msg, want)
+		}
+	}
+}